@@ -0,0 +1,75 @@
+package influxdb
+
+import (
+	"os"
+	"time"
+)
+
+// sendFailed logs a failed send and passes its error to the error handler.
+func (r *Reporter) sendFailed(err error) {
+	r.logger.repeatedf(LogError, "unable to send metrics to InfluxDB. err=%v", err)
+	if r.errorHandler != nil {
+		r.errorHandler(err)
+	}
+}
+
+// tooManyFailures calls the handler of WithMaxFailures, or exits if there is none.
+func (r *Reporter) tooManyFailures(err error) {
+	if r.failuresHandler != nil {
+		r.failuresHandler(r.failures, err)
+		return
+	}
+	r.logger.logf(LogError, "exiting after %d consecutive failed sends of metrics to InfluxDB. err=%v", r.failures, err)
+	os.Exit(1)
+}
+
+// written records the result of a write. With an adaptive interval, the writes after a failure
+// are spaced out, doubling the effective interval up to its maximum, until a write succeeds.
+func (r *Reporter) written(err error) {
+	if err == nil {
+		if r.failures > 0 && r.maxInterval > 0 {
+			r.logger.logf(LogInfo, "InfluxDB writes succeed again after %d failures, back to the normal interval", r.failures)
+		}
+		r.failures = 0
+		r.backoff = 0
+		r.recordState()
+		r.updateConnState()
+		return
+	}
+
+	r.sendFailed(err)
+
+	r.failures++
+	r.recordState()
+	r.updateConnState()
+	if r.maxFailures > 0 && r.failures == r.maxFailures {
+		r.tooManyFailures(err)
+	}
+	if r.maxInterval <= 0 {
+		return
+	}
+
+	every := r.writeInterval()
+	ticks := int64(1)
+	for i := 0; i < r.failures && time.Duration(ticks*2)*every <= r.maxInterval; i++ {
+		ticks *= 2
+	}
+	r.backoff = ticks - 1
+}
+
+// backingOff reports whether a write must be skipped because the previous ones failed.
+func (r *Reporter) backingOff() bool {
+	if r.backoff <= 0 {
+		return false
+	}
+	r.backoff--
+	return true
+}
+
+// writeInterval returns the interval between writes: the flush interval, or the interval.
+func (r *Reporter) writeInterval() time.Duration {
+	if r.flushInterval > 0 {
+		return r.flushInterval
+	}
+	return r.interval
+}
@@ -0,0 +1,120 @@
+package influxdb
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ticker ticks at each send.
+type ticker struct {
+	C    <-chan time.Time
+	Stop func()
+}
+
+// intervalTicker returns the ticker of the sends, ticking every interval. If sends are aligned,
+// it ticks on the multiples of the interval, and with a startup jitter, the ticks are delayed
+// by a random fraction of the interval.
+func (r *Reporter) intervalTicker() *ticker {
+	if r.interval <= 0 {
+		return &ticker{Stop: func() {}}
+	}
+	if !r.aligned && !r.jitter {
+		c, stop := r.clock.NewTicker(r.interval)
+		return &ticker{C: c, Stop: stop}
+	}
+
+	first := r.interval
+	if r.aligned {
+		now := r.clock.Now()
+		first = now.Truncate(r.interval).Add(r.interval).Sub(now)
+	}
+	if r.jitter {
+		// the same fraction of the new interval after SetInterval, so that the sends keep their place
+		first += time.Duration(r.jitterFraction * float64(r.interval))
+	}
+	return tickAfter(r.clock, first, r.interval)
+}
+
+// tickAfter returns a ticker ticking after first, then every d, on the clock.
+func tickAfter(clock Clock, first, d time.Duration) *ticker {
+	c := make(chan time.Time, 1)
+	stop := make(chan struct{})
+
+	go func() {
+		timer, stopTimer := clock.NewTimer(first)
+		select {
+		case <-timer:
+		case <-stop:
+			stopTimer()
+			return
+		}
+
+		t, stopTicker := clock.NewTicker(d)
+		defer stopTicker()
+		c <- clock.Now()
+
+		for {
+			select {
+			case tick := <-t:
+				// drop the tick if the reporter is late, like a ticker does
+				select {
+				case c <- tick:
+				default:
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return &ticker{C: c, Stop: func() {
+		once.Do(func() { close(stop) })
+	}}
+}
+
+// SetInterval changes the interval of a running reporter. The next send is one new interval away.
+func (r *Reporter) SetInterval(d time.Duration) error {
+	if d <= 0 {
+		return fmt.Errorf("invalid interval %s", d)
+	}
+
+	for {
+		select {
+		case r.intervals <- d:
+			return nil
+		default:
+			// replace the interval which wasn't applied yet
+			select {
+			case <-r.intervals:
+			default:
+			}
+		}
+	}
+}
+
+// setInterval applies a new interval, with the type intervals rounded to multiples of it.
+func (r *Reporter) setInterval(d time.Duration) {
+	for typ, td := range r.typeIntervals {
+		n := int64((td + d/2) / d)
+		if n < 1 {
+			n = 1
+		}
+		r.typeEvery[typ] = n
+	}
+	r.setTierIntervals(d)
+	atomic.StoreInt64(&r.watched, int64(d))
+	r.interval = d
+}
+
+// timestamp returns the time of the points of a send, truncated to the interval boundary if sends are aligned
+// or timestamps truncated.
+func (r *Reporter) timestamp() time.Time {
+	now := r.clock.Now()
+	if r.aligned || r.truncate {
+		return now.Truncate(r.interval)
+	}
+	return now
+}
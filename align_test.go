@@ -0,0 +1,46 @@
+package influxdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestStartupJitter(t *testing.T) {
+	tests := []struct {
+		name      string
+		interval  time.Duration
+		fraction  float64
+		wantFirst time.Duration
+	}{
+		{name: "interval", interval: 10 * time.Second, fraction: 0.5, wantFirst: 15 * time.Second},
+		{name: "scaled to a new interval", interval: 20 * time.Second, fraction: 0.5, wantFirst: 30 * time.Second},
+		{name: "no delay", interval: 10 * time.Second, fraction: 0, wantFirst: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			r := newTestReporter(t, unreachable, metrics.NewRegistry(), WithClock(clock), WithStartupJitter())
+			r.jitterFraction = tt.fraction
+			r.setInterval(tt.interval)
+			start := clock.Now()
+
+			tk := r.intervalTicker()
+			defer tk.Stop()
+			clock.waitTimers(1)
+			clock.Advance(tt.wantFirst - time.Millisecond)
+			select {
+			case <-tk.C:
+				t.Fatalf("ticked before %s", tt.wantFirst)
+			default:
+			}
+
+			clock.Advance(time.Millisecond)
+			if got := (<-tk.C).Sub(start); got != tt.wantFirst {
+				t.Errorf("first tick after %s, want %s", got, tt.wantFirst)
+			}
+		})
+	}
+}
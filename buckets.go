@@ -0,0 +1,86 @@
+package influxdb
+
+import (
+	"strconv"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// sampled is implemented by histograms and sliding window timers, which expose their sample.
+type sampled interface {
+	Sample() metrics.Sample
+}
+
+// sampledTimer is a timer which exposes its sample.
+type sampledTimer struct {
+	metrics.Timer
+	sample metrics.Sample
+}
+
+func (t *sampledTimer) Sample() metrics.Sample {
+	return t.sample
+}
+
+// ExponentialBuckets returns count bucket upper bounds, the first one being start and each one
+// being factor times the previous one.
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+	bounds := make([]float64, count)
+	for i := range bounds {
+		bounds[i] = start
+		start *= factor
+	}
+	return bounds
+}
+
+// bucketLabels returns the labels of the buckets, the last one being the +Inf bucket.
+func bucketLabels(bounds []float64) []string {
+	labels := make([]string, len(bounds)+1)
+	for i, b := range bounds {
+		labels[i] = strconv.FormatFloat(b, 'f', -1, 64)
+	}
+	labels[len(bounds)] = "+Inf"
+	return labels
+}
+
+// bucketCounts returns the cumulative number of values lower than or equal to each bound,
+// the last count being the number of values. Values are divided by unit first.
+func bucketCounts(values []int64, unit float64, bounds []float64) []int64 {
+	counts := make([]int64, len(bounds)+1)
+	for _, v := range values {
+		f := float64(v) / unit
+		for i, b := range bounds {
+			if f <= b {
+				counts[i]++
+			}
+		}
+	}
+	counts[len(bounds)] = int64(len(values))
+	return counts
+}
+
+// bucketField returns the field name of a bucket, like le_100 or le_inf.
+func bucketField(label string) string {
+	if label == "+Inf" {
+		return "le_inf"
+	}
+	return "le_" + label
+}
+
+// buckets returns the cumulative bucket counts of a histogram or a timer exposing its sample,
+// or nil if buckets are disabled or the metric has no sample.
+func (r *Reporter) buckets(typ string, i interface{}) []int64 {
+	if len(r.bucketBounds) == 0 {
+		return nil
+	}
+
+	s, ok := i.(sampled)
+	if !ok {
+		return nil
+	}
+
+	unit := float64(1)
+	if typ == TypeTimer {
+		unit = float64(r.durationUnit.Nanoseconds())
+	}
+	return bucketCounts(s.Sample().Values(), unit, r.bucketBounds)
+}
@@ -0,0 +1,367 @@
+package influxdb
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/client"
+)
+
+// Client is a connection to InfluxDB which can be shared by several reporters,
+// so that they use a single HTTP client and a single ping loop.
+type Client struct {
+	mu     sync.RWMutex
+	config client.Config
+	client *client.Client
+
+	pingInterval time.Duration
+	maxBackoff   time.Duration
+	pingOnce     sync.Once
+	// closing stops the ping loop, and pinging waits for it to return
+	closing      chan struct{}
+	closeOnce    sync.Once
+	pinging      sync.WaitGroup
+	lazy         bool
+	logger       leveledLogger
+	errorHandler func(error)
+	clock        Clock
+	codec        Codec
+	transport    *TransportOptions
+	dial         func(ctx context.Context, network, address string) (net.Conn, error)
+	// http writes the compressed points, guarded by mu
+	http *http.Client
+	// pingErr is the error of the last ping, guarded by mu
+	pingErr error
+}
+
+// ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// WithPingInterval sets the interval at which the client pings InfluxDB, and recreates its HTTP client
+// when the ping fails. The default is 5 seconds; 0 disables pinging, for write-only proxies which
+// don't implement /ping.
+func WithPingInterval(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.pingInterval = d
+	}
+}
+
+// WithPingBackoff sets the maximum interval between pings while InfluxDB is unreachable. The interval doubles
+// after each failed ping, starting at the ping interval, up to max. The default is one minute.
+func WithPingBackoff(max time.Duration) ClientOption {
+	return func(c *Client) {
+		c.maxBackoff = max
+	}
+}
+
+// WithLazyReconnect disables pinging and recreates the HTTP client, which re-resolves the host name,
+// when a write fails instead. This avoids the idle network chatter of pinging for large fleets.
+func WithLazyReconnect() ClientOption {
+	return func(c *Client) {
+		c.lazy = true
+		c.pingInterval = 0
+	}
+}
+
+// WithClientLogger sets the logger of the client. The default logs with the log package.
+func WithClientLogger(l Logger) ClientOption {
+	return func(c *Client) {
+		c.logger.Logger = l
+	}
+}
+
+// WithClientLogLevel sets the verbosity of the client. The default is LogInfo; LogDebug logs
+// the encoded writes, truncated, and the answers of InfluxDB.
+func WithClientLogLevel(level LogLevel) ClientOption {
+	return func(c *Client) {
+		c.logger.level = level
+	}
+}
+
+// WithClientLogRepeats logs a ping failing with the same error at most once per window, with the number
+// of times it was repeated. The default is 5 minutes; 0 logs every failure.
+func WithClientLogRepeats(window time.Duration) ClientOption {
+	return func(c *Client) {
+		c.logger.repeats.window = window
+	}
+}
+
+// WithClientErrorHandler calls h with the error of each failed ping.
+func WithClientErrorHandler(h func(error)) ClientOption {
+	return func(c *Client) {
+		c.errorHandler = h
+	}
+}
+
+// WithClientClock sets the clock of the pings and their backoff, for tests. The default is the system clock.
+func WithClientClock(c Clock) ClientOption {
+	return func(cl *Client) {
+		cl.clock = c
+	}
+}
+
+// NewClient creates a client for the InfluxDB server at the given url. A url without a scheme, like influxdb:8086,
+// uses http, and an IPv6 literal needs brackets to have a port, like http://[fe80::1]:8086.
+func NewClient(url, username, password string, opts ...ClientOption) (*Client, error) {
+	u, err := parseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse InfluxDB url %s. err=%v", url, err)
+	}
+
+	c := &Client{
+		config: client.Config{
+			URL:      *u,
+			Username: username,
+			Password: password,
+		},
+		pingInterval: time.Second * 5,
+		maxBackoff:   time.Minute,
+		logger:       newLeveledLogger(),
+		clock:        realClock{},
+		closing:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.logger.clock = c.clock
+
+	if err := c.makeClient(); err != nil {
+		return nil, fmt.Errorf("unable to make InfluxDB client. err=%v", err)
+	}
+
+	return c, nil
+}
+
+func (c *Client) makeClient() error {
+	c.mu.RLock()
+	config := c.config
+	c.mu.RUnlock()
+
+	cl, err := client.NewClient(config)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.client = cl
+	old := c.http
+	if c.ownHTTP() {
+		c.http = c.newHTTPClient(config)
+	}
+	c.mu.Unlock()
+
+	// the writes in flight on the previous client still finish
+	if old != nil {
+		old.CloseIdleConnections()
+	}
+
+	return nil
+}
+
+func (c *Client) get() *client.Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.client
+}
+
+// Write writes a batch of points.
+func (c *Client) Write(bps client.BatchPoints) error {
+	_, err := c.get().Write(bps)
+	c.failed(err)
+	return err
+}
+
+// writeLines writes points encoded in the line protocol, with nanosecond timestamps.
+func (c *Client) writeLines(database string, data []byte) error {
+	if !c.logger.enabled(LogDebug) {
+		err := c.post(database, data)
+		c.failed(err)
+		return err
+	}
+
+	c.logger.logf(LogDebug, "writing %d bytes to database %s of %s:\n%s", len(data), database, c.get().Addr(), truncate(data))
+	start := c.clock.Now()
+	err := c.post(database, data)
+	if err != nil {
+		c.logger.logf(LogDebug, "InfluxDB rejected the write after %s. err=%v", since(c.clock, start), err)
+	} else {
+		c.logger.logf(LogDebug, "InfluxDB accepted the write after %s", since(c.clock, start))
+	}
+	c.failed(err)
+	return err
+}
+
+// post sends points encoded in the line protocol to InfluxDB.
+func (c *Client) post(database string, data []byte) error {
+	if c.ownHTTP() {
+		return c.postHTTP(database, data)
+	}
+	_, err := c.get().WriteLineProtocol(string(data), database, "", "", "")
+	return err
+}
+
+// failed recreates the HTTP client after a failed write, if the client reconnects lazily.
+func (c *Client) failed(err error) {
+	if err != nil && c.lazy {
+		if mErr := c.makeClient(); mErr != nil {
+			c.logger.logf(LogError, "unable to make InfluxDB client. err=%v", mErr)
+		}
+	}
+}
+
+// Ping checks that InfluxDB is reachable.
+func (c *Client) Ping() error {
+	if c.ownHTTP() {
+		return c.pingHTTP()
+	}
+	_, _, err := c.get().Ping()
+	return err
+}
+
+// lastPingError returns the error of the last ping, or nil if it succeeded or the client doesn't ping.
+func (c *Client) lastPingError() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.pingErr
+}
+
+// waitReachable pings InfluxDB until it answers, up to retries more times, doubling the wait after each failure.
+func (c *Client) waitReachable(retries int, backoff time.Duration) error {
+	err := c.Ping()
+	for i := 0; err != nil && i < retries; i++ {
+		sleep(c.clock, backoff)
+		backoff *= 2
+		err = c.Ping()
+	}
+	if err != nil {
+		return fmt.Errorf("InfluxDB is not reachable after %d attempts. err=%v", retries+1, err)
+	}
+	return nil
+}
+
+func (c *Client) query(command string) (*client.Response, error) {
+	var resp *client.Response
+	var err error
+	if c.ownHTTP() {
+		resp, err = c.queryHTTP(command)
+	} else {
+		resp, err = c.get().Query(client.Query{Command: command})
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.Error(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// databaseExists reports whether a database exists.
+func (c *Client) databaseExists(database string) (bool, error) {
+	resp, err := c.query("SHOW DATABASES")
+	if err != nil {
+		return false, err
+	}
+
+	for _, res := range resp.Results {
+		for _, row := range res.Series {
+			for _, v := range row.Values {
+				if len(v) > 0 && v[0] == database {
+					return true, nil
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+// createDatabase creates a database if it doesn't exist.
+func (c *Client) createDatabase(database string) error {
+	_, err := c.query(fmt.Sprintf("CREATE DATABASE %q", database))
+	return err
+}
+
+// startPinging starts the ping loop of the client, once for all the reporters sharing it.
+func (c *Client) startPinging() {
+	if c.pingInterval <= 0 {
+		return
+	}
+
+	c.pingOnce.Do(func() {
+		c.pinging.Add(1)
+		go c.pingLoop()
+	})
+}
+
+// Close stops the ping loop of the client and closes its idle connections. A reporter closes the client
+// it created when it stops, but not a client given with WithClient, which must be closed once all its
+// reporters are stopped.
+func (c *Client) Close() {
+	// the ping loop is either running or never starts
+	c.pingOnce.Do(func() {})
+	c.closeOnce.Do(func() {
+		close(c.closing)
+	})
+	c.pinging.Wait()
+
+	c.mu.RLock()
+	hc := c.http
+	c.mu.RUnlock()
+	if hc != nil {
+		hc.CloseIdleConnections()
+	}
+}
+
+func (c *Client) pingLoop() {
+	defer c.pinging.Done()
+
+	wait := c.pingInterval
+	failures := 0
+
+	for {
+		ch, stop := c.clock.NewTimer(wait)
+		select {
+		case <-ch:
+		case <-c.closing:
+			stop()
+			return
+		}
+
+		err := c.Ping()
+		c.mu.Lock()
+		c.pingErr = err
+		c.mu.Unlock()
+		if err == nil {
+			if failures > 0 {
+				c.logger.logf(LogInfo, "InfluxDB is reachable again after %d failed pings", failures)
+			}
+			failures = 0
+			wait = c.pingInterval
+			continue
+		}
+
+		if c.errorHandler != nil {
+			c.errorHandler(fmt.Errorf("unable to ping InfluxDB. err=%v", err))
+		}
+		if failures == 0 {
+			c.logger.repeatedf(LogWarn, "got error while sending a ping to InfluxDB, recreating client until it succeeds. err=%v", err)
+		}
+		failures++
+
+		if err = c.makeClient(); err != nil && failures == 1 {
+			c.logger.logf(LogError, "unable to make InfluxDB client. err=%v", err)
+		}
+
+		wait *= 2
+		if wait > c.maxBackoff {
+			wait = c.maxBackoff
+		}
+		if wait < c.pingInterval {
+			wait = c.pingInterval
+		}
+	}
+}
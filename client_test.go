@@ -0,0 +1,28 @@
+package influxdb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLazyReconnectClosesIdleConns(t *testing.T) {
+	influx := newFakeInflux(t)
+	c := newTestClient(t, influx.URL, WithLazyReconnect(), WithGzip(), WithClientLogger(NopLogger))
+
+	if err := c.writeLines("db", []byte("m value=1i\n")); err != nil {
+		t.Fatalf("unable to write. err=%v", err)
+	}
+
+	// the failed write replaces the HTTP client, whose idle connection must not leak
+	influx.failing("db", true)
+	if err := c.writeLines("db", []byte("m value=2i\n")); err == nil {
+		t.Fatal("the write succeeded, want it to fail")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for influx.closedConns() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the idle connection of the previous HTTP client wasn't closed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
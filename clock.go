@@ -0,0 +1,51 @@
+package influxdb
+
+import (
+	"time"
+)
+
+// Clock is the source of the time, the tickers and the timers of the reporters and clients,
+// so that tests can control the scheduling of the sends, the pings and the backoffs.
+type Clock interface {
+	Now() time.Time
+	// NewTicker returns a channel ticking every d, and a function stopping it.
+	NewTicker(d time.Duration) (<-chan time.Time, func())
+	// NewTimer returns a channel receiving the time once d elapsed, and a function stopping it.
+	NewTimer(d time.Duration) (<-chan time.Time, func())
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTicker(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
+
+func (realClock) NewTimer(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTimer(d)
+	return t.C, func() { t.Stop() }
+}
+
+// sleep waits for d on the clock.
+func sleep(c Clock, d time.Duration) {
+	ch, _ := c.NewTimer(d)
+	<-ch
+}
+
+// since returns the time elapsed since t on the clock.
+func since(c Clock, t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// tick returns a channel ticking every d on the clock and a function stopping it,
+// or a nil channel if d is not positive, like time.Tick.
+func tick(c Clock, d time.Duration) (<-chan time.Time, func()) {
+	if d <= 0 {
+		return nil, func() {}
+	}
+	return c.NewTicker(d)
+}
@@ -0,0 +1,111 @@
+package influxdb
+
+import (
+	"sync"
+	"time"
+)
+
+// fakeClock is a Clock whose time only moves with Advance, which delivers the ticks and timers due
+// and waits for each of them to be received.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	c     chan time.Time
+	done  chan struct{}
+	next  time.Time
+	every time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) (<-chan time.Time, func()) {
+	return c.add(d, d)
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) (<-chan time.Time, func()) {
+	return c.add(d, 0)
+}
+
+func (c *fakeClock) add(d, every time.Duration) (<-chan time.Time, func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{c: make(chan time.Time), done: make(chan struct{}), next: c.now.Add(d), every: every}
+	c.timers = append(c.timers, t)
+
+	var once sync.Once
+	return t.c, func() {
+		once.Do(func() {
+			close(t.done)
+			c.remove(t)
+		})
+	}
+}
+
+func (c *fakeClock) remove(t *fakeTimer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, other := range c.timers {
+		if other == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return
+		}
+	}
+}
+
+// waitTimers waits until n tickers and timers are running.
+func (c *fakeClock) waitTimers(n int) {
+	for {
+		c.mu.Lock()
+		running := len(c.timers)
+		c.mu.Unlock()
+		if running >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Advance moves the time forward by d, and delivers a tick to each ticker and timer due, in no order.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+	var due []*fakeTimer
+	kept := c.timers[:0]
+	for _, t := range c.timers {
+		if t.next.After(now) {
+			kept = append(kept, t)
+			continue
+		}
+		due = append(due, t)
+		if t.every > 0 {
+			for !t.next.After(now) {
+				t.next = t.next.Add(t.every)
+			}
+			kept = append(kept, t)
+		}
+	}
+	c.timers = kept
+	c.mu.Unlock()
+
+	for _, t := range due {
+		select {
+		case t.c <- now:
+		case <-t.done:
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// Command influx-loadgen registers synthetic metrics and reports them to InfluxDB with this package,
+// to benchmark the sizing of an InfluxDB with the traffic of the reporter:
+//
+//	influx-loadgen -config metrics.yaml -counters 10000 -timers 1000 -rate 10 -duration 10m
+//
+// The configuration comes from a YAML or TOML file given with -config, or from the environment
+// variables read by NewFromEnv. The state of the writes is printed at each interval, and a summary
+// once the duration is over or the command is interrupted.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+	influxdb "github.com/vrischmann/go-metrics-influxdb"
+	"github.com/vrischmann/go-metrics-influxdb/influxconfig"
+)
+
+type options struct {
+	configPath string
+	counters   int
+	gauges     int
+	timers     int
+	rate       int
+	latency    time.Duration
+	interval   time.Duration
+	duration   time.Duration
+	batchSize  int
+	workers    int
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.configPath, "config", "", "YAML or TOML configuration file; the environment is used if empty")
+	flag.IntVar(&opts.counters, "counters", 1000, "number of counters")
+	flag.IntVar(&opts.gauges, "gauges", 0, "number of gauges")
+	flag.IntVar(&opts.timers, "timers", 100, "number of timers")
+	flag.IntVar(&opts.rate, "rate", 10, "updates of each metric per second")
+	flag.DurationVar(&opts.latency, "latency", 20*time.Millisecond, "mean of the durations recorded by the timers")
+	flag.DurationVar(&opts.interval, "interval", 0, "interval between sends; the configured one is used if 0")
+	flag.DurationVar(&opts.duration, "duration", time.Minute, "how long to report; 0 reports until interrupted")
+	flag.IntVar(&opts.batchSize, "batch", 0, "maximum number of points per write; 0 writes each send at once")
+	flag.IntVar(&opts.workers, "workers", 1, "number of goroutines building the points")
+	flag.Parse()
+
+	if err := run(opts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(opts options) error {
+	if opts.counters < 0 || opts.gauges < 0 || opts.timers < 0 {
+		return fmt.Errorf("invalid number of metrics")
+	}
+	if opts.rate <= 0 {
+		return fmt.Errorf("invalid rate %d", opts.rate)
+	}
+
+	cfg, err := loadConfig(opts.configPath)
+	if err != nil {
+		return err
+	}
+	if opts.interval > 0 {
+		cfg.Interval = opts.interval
+	}
+
+	reg := metrics.NewRegistry()
+	m := register(reg, opts)
+
+	rep, err := influxdb.NewWithConfig(reg, cfg,
+		influxdb.WithMaxBatchSize(opts.batchSize),
+		influxdb.WithWorkers(opts.workers),
+	)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("reporting %d counters, %d gauges and %d timers to %s every %s\n", opts.counters, opts.gauges, opts.timers, cfg.URL, cfg.Interval)
+
+	stop := make(chan struct{})
+	go m.update(opts.rate, opts.latency, stop)
+	go rep.Run()
+
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+
+	var done <-chan time.Time
+	if opts.duration > 0 {
+		done = time.After(opts.duration)
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+loop:
+	for {
+		select {
+		case <-ticker.C:
+			printStatus(rep.Status())
+		case <-done:
+			break loop
+		case <-interrupted:
+			break loop
+		}
+	}
+
+	close(stop)
+	rep.Stop()
+
+	st := rep.Status()
+	elapsed := time.Since(start)
+	fmt.Printf("wrote %d points in %s, %.0f points/s, %d failed writes\n", st.Written, elapsed.Round(time.Second), float64(st.Written)/elapsed.Seconds(), st.Errors)
+	if st.LastError != nil {
+		fmt.Printf("last error at %s: %v\n", st.LastErrorTime.Format(time.RFC3339), st.LastError)
+	}
+
+	return nil
+}
+
+func loadConfig(path string) (influxdb.Config, error) {
+	if path != "" {
+		return influxconfig.Load(path)
+	}
+	return influxdb.ConfigFromEnv()
+}
+
+// synthetic are the metrics updated by the load generator.
+type synthetic struct {
+	counters []metrics.Counter
+	gauges   []metrics.Gauge
+	timers   []metrics.Timer
+}
+
+func register(reg metrics.Registry, opts options) *synthetic {
+	m := &synthetic{}
+	for i := 0; i < opts.counters; i++ {
+		m.counters = append(m.counters, metrics.GetOrRegisterCounter(fmt.Sprintf("loadgen.counter.%d", i), reg))
+	}
+	for i := 0; i < opts.gauges; i++ {
+		m.gauges = append(m.gauges, metrics.GetOrRegisterGauge(fmt.Sprintf("loadgen.gauge.%d", i), reg))
+	}
+	for i := 0; i < opts.timers; i++ {
+		m.timers = append(m.timers, metrics.GetOrRegisterTimer(fmt.Sprintf("loadgen.timer.%d", i), reg))
+	}
+	return m
+}
+
+// minPeriod is the shortest period between two rounds of updates. Higher rates update the metrics
+// several times per round.
+const minPeriod = time.Millisecond
+
+// update updates every metric rate times per second until stop is closed, the timers with
+// exponentially distributed durations around latency.
+func (m *synthetic) update(rate int, latency time.Duration, stop chan struct{}) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	period := time.Second / time.Duration(rate)
+	if period < minPeriod {
+		period = minPeriod
+	}
+	perTick := float64(rate) * period.Seconds()
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	due := 0.0
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+
+		due += perTick
+		for ; due >= 1; due-- {
+			m.updateOnce(rnd, latency)
+		}
+	}
+}
+
+// updateOnce updates every metric once.
+func (m *synthetic) updateOnce(rnd *rand.Rand, latency time.Duration) {
+	for _, c := range m.counters {
+		c.Inc(1)
+	}
+	for _, g := range m.gauges {
+		g.Update(rnd.Int63n(1000))
+	}
+	for _, t := range m.timers {
+		t.Update(time.Duration(rnd.ExpFloat64() * float64(latency)))
+	}
+}
+
+func printStatus(st influxdb.Status) {
+	if st.LastError != nil && !st.LastErrorTime.Before(st.LastSuccess) {
+		fmt.Printf("%s: %d points written, %d failed writes, %d queued sends, last error: %v\n", st.State, st.Written, st.Errors, st.Queued, st.LastError)
+		return
+	}
+	fmt.Printf("%s: %d points written, last write of %d points at %s, %d queued sends\n", st.State, st.Written, st.LastPoints, st.LastSuccess.Format(time.RFC3339), st.Queued)
+}
@@ -0,0 +1,156 @@
+// Command influx-report sends metrics to InfluxDB once, printing the line protocol it writes
+// and the answer of the server, to check connectivity, credentials and schemas.
+//
+// The configuration comes from a YAML or TOML file given with -config, or from the environment
+// variables read by NewFromEnv. The metrics are samples of each type, or read from a file given
+// with -metrics, with one metric per line:
+//
+//	counter requests 42
+//	gauge queue.size 7
+//	gaugefloat load 0.75
+//	meter events 10
+//	histogram sizes 512
+//	timer latency 35ms
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+	influxdb "github.com/vrischmann/go-metrics-influxdb"
+	"github.com/vrischmann/go-metrics-influxdb/influxconfig"
+)
+
+func main() {
+	var (
+		configPath  = flag.String("config", "", "YAML or TOML configuration file; the environment is used if empty")
+		metricsPath = flag.String("metrics", "", "file of metrics to send; samples of each type are sent if empty")
+	)
+	flag.Parse()
+
+	if err := run(*configPath, *metricsPath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(configPath, metricsPath string) error {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	reg := metrics.NewRegistry()
+	if metricsPath == "" {
+		registerSamples(reg)
+	} else if err := readMetrics(reg, metricsPath); err != nil {
+		return err
+	}
+
+	rep, err := influxdb.NewWithConfig(reg, cfg,
+		influxdb.WithClientOptions(influxdb.WithPingInterval(0)),
+		influxdb.WithEcho(os.Stdout),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := rep.Send(); err != nil {
+		return fmt.Errorf("InfluxDB rejected the write: %v", err)
+	}
+	fmt.Printf("InfluxDB accepted the write to %s\n", cfg.URL)
+
+	return nil
+}
+
+func loadConfig(path string) (influxdb.Config, error) {
+	if path != "" {
+		return influxconfig.Load(path)
+	}
+	return influxdb.ConfigFromEnv()
+}
+
+// registerSamples registers a metric of each type.
+func registerSamples(reg metrics.Registry) {
+	metrics.GetOrRegisterCounter("influx_report.counter", reg).Inc(42)
+	metrics.GetOrRegisterGauge("influx_report.gauge", reg).Update(7)
+	metrics.GetOrRegisterGaugeFloat64("influx_report.gauge_float", reg).Update(0.75)
+	metrics.GetOrRegisterMeter("influx_report.meter", reg).Mark(10)
+
+	h := metrics.GetOrRegisterHistogram("influx_report.histogram", reg, metrics.NewUniformSample(1028))
+	t := metrics.GetOrRegisterTimer("influx_report.timer", reg)
+	for i := int64(1); i <= 100; i++ {
+		h.Update(i)
+		t.Update(time.Duration(i) * time.Millisecond)
+	}
+}
+
+// readMetrics registers the metrics of a file, with a type, a name and a value per line.
+// Empty lines and lines starting with # are ignored.
+func readMetrics(reg metrics.Registry, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to open metrics %s. err=%v", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := addMetric(reg, strings.Fields(line)); err != nil {
+			return fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func addMetric(reg metrics.Registry, fields []string) error {
+	if len(fields) != 3 {
+		return fmt.Errorf("expected a type, a name and a value, got %q", strings.Join(fields, " "))
+	}
+	typ, name, value := fields[0], fields[1], fields[2]
+
+	switch typ {
+	case "timer":
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		metrics.GetOrRegisterTimer(name, reg).Update(d)
+		return nil
+	case "gaugefloat":
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		metrics.GetOrRegisterGaugeFloat64(name, reg).Update(v)
+		return nil
+	}
+
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return err
+	}
+	switch typ {
+	case "counter":
+		metrics.GetOrRegisterCounter(name, reg).Inc(v)
+	case "gauge":
+		metrics.GetOrRegisterGauge(name, reg).Update(v)
+	case "meter":
+		metrics.GetOrRegisterMeter(name, reg).Mark(v)
+	case "histogram":
+		metrics.GetOrRegisterHistogram(name, reg, metrics.NewUniformSample(1028)).Update(v)
+	default:
+		return fmt.Errorf("unknown metric type %s", typ)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package influxdb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// failingCodec fails to compress.
+type failingCodec struct{}
+
+func (failingCodec) Encoding() string { return "broken" }
+
+func (failingCodec) Compress(dst *bytes.Buffer, data []byte) error {
+	return errors.New("out of memory")
+}
+
+func TestGzip(t *testing.T) {
+	influx := newFakeInflux(t)
+	c := newTestClient(t, influx.URL, WithGzip())
+
+	// the compressors and the buffers are shared by the concurrent writes
+	want := make(map[string]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		data := strings.Repeat("requests.count value=1i\n", i+1)
+		want[data] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.writeLines("db", []byte(data)); err != nil {
+				t.Errorf("unable to write. err=%v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	influx.mu.Lock()
+	defer influx.mu.Unlock()
+	if len(influx.writes) != len(want) {
+		t.Fatalf("got %d writes, want %d", len(influx.writes), len(want))
+	}
+	for _, w := range influx.writes {
+		if w.encoding != "gzip" {
+			t.Errorf("the encoding is %q, want gzip", w.encoding)
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(w.body))
+		if err != nil {
+			t.Fatalf("unable to read the gzip body. err=%v", err)
+		}
+		body, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("unable to decompress the body. err=%v", err)
+		}
+		if !want[string(body)] {
+			t.Errorf("unexpected body %q", body)
+		}
+		delete(want, string(body))
+	}
+}
+
+func TestCodecError(t *testing.T) {
+	influx := newFakeInflux(t)
+	c := newTestClient(t, influx.URL, WithCodec(failingCodec{}), WithClientLogger(NopLogger))
+
+	err := c.writeLines("db", []byte("requests.count value=1i\n"))
+	if err == nil || !strings.Contains(err.Error(), "unable to compress metrics with broken") {
+		t.Errorf("got the error %v, want the codec failure", err)
+	}
+	if got := influx.lines("db"); len(got) != 0 {
+		t.Errorf("got the lines %q, want nothing written", got)
+	}
+}
@@ -0,0 +1,27 @@
+package influxdb
+
+import (
+	"github.com/rcrowley/go-metrics"
+)
+
+// Collector updates metrics of a registry. Collectors are called before each send,
+// so that the metrics they maintain are fresh.
+type Collector func()
+
+func (r *Reporter) runCollectors() {
+	for _, c := range r.collectors {
+		r.safeCollect(c)
+	}
+}
+
+// RuntimeCollector registers the Go runtime memory and GC stats of go-metrics into the registry
+// and returns a collector capturing them.
+func RuntimeCollector(reg metrics.Registry) Collector {
+	metrics.RegisterRuntimeMemStats(reg)
+	metrics.RegisterDebugGCStats(reg)
+
+	return func() {
+		metrics.CaptureRuntimeMemStatsOnce(reg)
+		metrics.CaptureDebugGCStatsOnce(reg)
+	}
+}
@@ -0,0 +1,180 @@
+package influxdb
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Config configures a reporter created by NewWithConfig, and a running reporter with Update.
+type Config struct {
+	// URL, Username and Password are the InfluxDB server and its credentials.
+	URL      string `yaml:"url" toml:"url"`
+	Username string `yaml:"username" toml:"username"`
+	Password string `yaml:"password" toml:"password"`
+	// Database is the default database of the points.
+	Database string `yaml:"database" toml:"database"`
+	// Interval is the interval between sends.
+	Interval time.Duration `yaml:"interval" toml:"interval"`
+	// Tags are added to all the points.
+	Tags map[string]string `yaml:"tags" toml:"tags"`
+	// Include and Exclude filter the metrics by name, like WithInclude and WithExclude.
+	Include []string `yaml:"include" toml:"include"`
+	Exclude []string `yaml:"exclude" toml:"exclude"`
+	// Routes send the metrics to other databases, like WithRoutes.
+	Routes []Route `yaml:"routes" toml:"routes"`
+}
+
+// Route sends the metrics whose names match a pattern to a database.
+type Route struct {
+	Pattern  string `yaml:"pattern" toml:"pattern"`
+	Database string `yaml:"database" toml:"database"`
+}
+
+// route is a compiled Route.
+type route struct {
+	re       *regexp.Regexp
+	database string
+}
+
+func compileRoutes(routes []Route) ([]route, error) {
+	res := make([]route, 0, len(routes))
+	for _, rt := range routes {
+		re, err := regexp.Compile(rt.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("unable to compile route pattern %q. err=%v", rt.Pattern, err)
+		}
+		res = append(res, route{re: re, database: rt.Database})
+	}
+	return res, nil
+}
+
+// routeDatabase returns the database of the first route matching a name, or the default database.
+func (r *Reporter) routeDatabase(name string) string {
+	for _, rt := range r.routes {
+		if rt.re.MatchString(name) {
+			return rt.database
+		}
+	}
+	return r.database
+}
+
+// Update atomically replaces the tags, the filters, the routes and the destination of a reporter, between two sends.
+// An empty URL or database, or a zero interval, keeps the current one. Changing the URL of a client
+// shared with WithClient changes it for all its reporters.
+func (r *Reporter) Update(cfg Config) error {
+	include, err := compilePatterns(cfg.Include)
+	if err != nil {
+		return err
+	}
+	exclude, err := compilePatterns(cfg.Exclude)
+	if err != nil {
+		return err
+	}
+	routes, err := compileRoutes(cfg.Routes)
+	if err != nil {
+		return err
+	}
+	if cfg.Interval < 0 {
+		return fmt.Errorf("invalid interval %s", cfg.Interval)
+	}
+
+	if cfg.URL != "" {
+		if err := r.client.reconfigure(cfg.URL, cfg.Username, cfg.Password); err != nil {
+			return err
+		}
+	}
+
+	r.cfgMu.Lock()
+	r.tags = cfg.Tags
+	r.includePatterns, r.include = cfg.Include, include
+	r.excludePatterns, r.exclude = cfg.Exclude, exclude
+	r.routeSpecs, r.routes = cfg.Routes, routes
+	if cfg.Database != "" {
+		r.database = cfg.Database
+	}
+	r.cfgMu.Unlock()
+
+	if cfg.Interval > 0 {
+		return r.SetInterval(cfg.Interval)
+	}
+	return nil
+}
+
+// reconfigure points the client to another server, or changes its credentials.
+func (c *Client) reconfigure(url, username, password string) error {
+	u, err := parseURL(url)
+	if err != nil {
+		return fmt.Errorf("unable to parse InfluxDB url %s. err=%v", url, err)
+	}
+
+	c.mu.Lock()
+	c.config.URL = *u
+	c.config.Username = username
+	c.config.Password = password
+	c.mu.Unlock()
+
+	if err := c.makeClient(); err != nil {
+		return fmt.Errorf("unable to make InfluxDB client. err=%v", err)
+	}
+	return nil
+}
+
+// ConfigErrors are the problems found in a configuration by Validate.
+type ConfigErrors []error
+
+func (e ConfigErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "invalid InfluxDB reporter configuration: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks a configuration and returns all its problems as ConfigErrors, or nil.
+func (cfg Config) Validate() error {
+	var errs ConfigErrors
+
+	if _, err := parseURL(cfg.URL); err != nil {
+		errs = append(errs, fmt.Errorf("unable to parse url %q. err=%v", cfg.URL, err))
+	}
+	if cfg.Password != "" && cfg.Username == "" {
+		errs = append(errs, fmt.Errorf("password set without a username, it would not be sent"))
+	}
+
+	if cfg.Database == "" {
+		errs = append(errs, fmt.Errorf("empty database"))
+	}
+	if cfg.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("invalid interval %s", cfg.Interval))
+	}
+
+	if _, err := compilePatterns(cfg.Include); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := compilePatterns(cfg.Exclude); err != nil {
+		errs = append(errs, err)
+	}
+	for _, in := range cfg.Include {
+		for _, ex := range cfg.Exclude {
+			if in == ex {
+				errs = append(errs, fmt.Errorf("pattern %q is both included and excluded", in))
+			}
+		}
+	}
+
+	for _, rt := range cfg.Routes {
+		if _, err := regexp.Compile(rt.Pattern); err != nil {
+			errs = append(errs, fmt.Errorf("unable to compile route pattern %q. err=%v", rt.Pattern, err))
+		}
+		if rt.Database == "" {
+			errs = append(errs, fmt.Errorf("route %q has an empty database", rt.Pattern))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package influxdb
+
+// ConnState is the state of the connection of a reporter to InfluxDB, given the outcome of its writes
+// and of the pings of its client.
+type ConnState int
+
+const (
+	// Connected means the last write and the last ping succeeded.
+	Connected ConnState = iota
+	// Degraded means the last write or the last ping failed.
+	Degraded
+	// Disconnected means both failed, or disconnectedFailures sends failed in a row.
+	Disconnected
+)
+
+// disconnectedFailures is the number of consecutive failed sends after which the reporter is disconnected,
+// for the clients which don't ping.
+const disconnectedFailures = 3
+
+func (s ConnState) String() string {
+	switch s {
+	case Connected:
+		return "connected"
+	case Degraded:
+		return "degraded"
+	case Disconnected:
+		return "disconnected"
+	}
+	return "unknown"
+}
+
+// updateConnState computes the state of the connection after a write, and notifies its changes.
+func (r *Reporter) updateConnState() {
+	pingErr := r.client.lastPingError()
+
+	state := Connected
+	switch {
+	case r.failures >= disconnectedFailures || (r.failures > 0 && pingErr != nil):
+		state = Disconnected
+	case r.failures > 0 || pingErr != nil:
+		state = Degraded
+	}
+
+	from := r.status.connState(state)
+	if from == state {
+		return
+	}
+
+	level := LogWarn
+	if state < from {
+		level = LogInfo
+	}
+	r.logger.logf(level, "InfluxDB connection state changed from %s to %s", from, state)
+	if r.connStateHandler != nil {
+		r.connStateHandler(from, state)
+	}
+}
+
+// connState records the state of the connection and returns the previous one.
+func (s *status) connState(state ConnState) ConnState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from := s.s.State
+	s.s.State = state
+	return from
+}
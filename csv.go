@@ -0,0 +1,87 @@
+package influxdb
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// csvTagPrefix and csvFieldPrefix prefix the tag and field columns of the CSV.
+const (
+	csvTagPrefix   = "tag:"
+	csvFieldPrefix = "field:"
+)
+
+// EncodeCSV writes points as CSV: a header with the timestamp, the measurement, then a column per tag key
+// and per field key of the points, sorted, and a row per point. The tag and field columns are prefixed with
+// tag: and field:, like tag:host and field:value, so that they never collide with each other or with the
+// timestamp and measurement columns. Points without fields are skipped, and so are raw points, which have no columns.
+func EncodeCSV(w io.Writer, pts []Point) error {
+	tagSet := make(map[string]bool)
+	fieldSet := make(map[string]bool)
+	for i := range pts {
+		if len(pts[i].Fields) == 0 {
+			continue
+		}
+		for k := range pts[i].Tags {
+			tagSet[k] = true
+		}
+		for k := range pts[i].Fields {
+			fieldSet[k] = true
+		}
+	}
+	tags := sortedSet(tagSet)
+	fields := sortedSet(fieldSet)
+
+	cw := csv.NewWriter(w)
+	header := []string{"timestamp", "measurement"}
+	for _, k := range tags {
+		header = append(header, csvTagPrefix+k)
+	}
+	for _, k := range fields {
+		header = append(header, csvFieldPrefix+k)
+	}
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("unable to write CSV. err=%v", err)
+	}
+
+	row := make([]string, len(header))
+	for i := range pts {
+		pt := &pts[i]
+		if len(pt.Fields) == 0 {
+			continue
+		}
+
+		row[0] = pt.Time.Format(time.RFC3339Nano)
+		row[1] = pt.Measurement
+		for j, k := range tags {
+			row[2+j] = pt.Tags[k]
+		}
+		for j, k := range fields {
+			row[2+len(tags)+j] = ""
+			if v, ok := pt.Fields[k]; ok {
+				row[2+len(tags)+j] = fmt.Sprint(v)
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("unable to write CSV. err=%v", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("unable to write CSV. err=%v", err)
+	}
+	return nil
+}
+
+func sortedSet(set map[string]bool) []string {
+	res := make([]string, 0, len(set))
+	for k := range set {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
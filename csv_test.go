@@ -0,0 +1,27 @@
+package influxdb
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestEncodeCSV(t *testing.T) {
+	at := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	pts := []Point{
+		{Measurement: "a", Tags: map[string]string{"host": "h1", "count": "x"}, Fields: map[string]interface{}{"count": int64(1)}, Time: at},
+		{Measurement: "b", Fields: map[string]interface{}{"value": 1.5, "timestamp": int64(2)}, Time: at},
+		{Measurement: "c", Tags: map[string]string{"host": "h2"}},
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeCSV(&buf, pts); err != nil {
+		t.Fatalf("unable to encode. err=%v", err)
+	}
+	want := "timestamp,measurement,tag:count,tag:host,field:count,field:timestamp,field:value\n" +
+		"2020-01-01T00:00:00Z,a,x,h1,1,,\n" +
+		"2020-01-01T00:00:00Z,b,,,,2,1.5\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
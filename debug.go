@@ -0,0 +1,95 @@
+package influxdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// lastBatches keeps a copy of the last batch written to each database, once a handler serves them.
+type lastBatches struct {
+	on      atomic.Bool
+	mu      sync.Mutex
+	batches map[string]lastBatch
+}
+
+type lastBatch struct {
+	time  time.Time
+	lines []byte
+}
+
+// record keeps a copy of a batch encoded in the line protocol.
+func (l *lastBatches) record(database string, lines []byte, now time.Time) {
+	if !l.on.Load() {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.batches == nil {
+		l.batches = make(map[string]lastBatch)
+	}
+	b := l.batches[database]
+	b.time = now
+	b.lines = append(b.lines[:0], lines...)
+	l.batches[database] = b
+}
+
+// sorted returns the databases and a copy of their last batch.
+func (l *lastBatches) sorted() ([]string, map[string]lastBatch) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	dbs := make([]string, 0, len(l.batches))
+	batches := make(map[string]lastBatch, len(l.batches))
+	for db, b := range l.batches {
+		dbs = append(dbs, db)
+		batches[db] = lastBatch{time: b.time, lines: append([]byte(nil), b.lines...)}
+	}
+	sort.Strings(dbs)
+	return dbs, batches
+}
+
+type jsonBatch struct {
+	Database string      `json:"database"`
+	Time     time.Time   `json:"time"`
+	Points   []jsonPoint `json:"points"`
+}
+
+// LastBatchHandler returns a handler serving the last batch written to each database, for a debug mux.
+// It serves the line protocol, preceded by a comment with the database and the time of the write,
+// or JSON with ?format=json. The batches are only kept once the handler is created.
+func (r *Reporter) LastBatchHandler() http.Handler {
+	r.lastBatches.on.Store(true)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		dbs, batches := r.lastBatches.sorted()
+
+		if req.URL.Query().Get("format") != "json" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			for _, db := range dbs {
+				fmt.Fprintf(w, "# database %s written at %s\n", db, batches[db].time.Format(time.RFC3339Nano))
+				w.Write(batches[db].lines)
+			}
+			return
+		}
+
+		res := make([]jsonBatch, 0, len(dbs))
+		for _, db := range dbs {
+			pts, err := parseJSON(batches[db].lines)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("unable to parse the batch of %s. err=%v", db, err), http.StatusInternalServerError)
+				return
+			}
+			res = append(res, jsonBatch{Database: db, Time: batches[db].time, Points: pts})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+	})
+}
@@ -0,0 +1,65 @@
+package influxdb
+
+import (
+	"sort"
+	"strings"
+)
+
+// RegistryDiff are the names of the metrics which appeared in the registries or vanished from them
+// between two sends, sorted.
+type RegistryDiff struct {
+	Added   []string
+	Removed []string
+}
+
+// maxLoggedNames is the number of names logged for each change of the registries.
+const maxLoggedNames = 10
+
+// diffRegistry compares the names seen by a send with the ones of the previous send, and reports the changes.
+// The first send only records the names.
+func (r *Reporter) diffRegistry(seen map[string]struct{}) {
+	known := r.knownNames
+	r.knownNames = seen
+	if known == nil {
+		return
+	}
+
+	var diff RegistryDiff
+	for name := range seen {
+		if _, ok := known[name]; !ok {
+			diff.Added = append(diff.Added, name)
+		}
+	}
+	for name := range known {
+		if _, ok := seen[name]; !ok {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+	if len(diff.Added) == 0 && len(diff.Removed) == 0 {
+		return
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+
+	if len(diff.Added) > 0 {
+		r.logger.logf(LogInfo, "%d metrics appeared in the registry. names=%s", len(diff.Added), loggedNames(diff.Added))
+	}
+	if len(diff.Removed) > 0 {
+		r.logger.logf(LogWarn, "%d metrics vanished from the registry. names=%s", len(diff.Removed), loggedNames(diff.Removed))
+	}
+	if r.self != nil {
+		r.self.added.Inc(int64(len(diff.Added)))
+		r.self.removed.Inc(int64(len(diff.Removed)))
+	}
+	if r.diffHandler != nil {
+		r.diffHandler(diff)
+	}
+}
+
+// loggedNames joins the first names of a change.
+func loggedNames(names []string) string {
+	if len(names) <= maxLoggedNames {
+		return strings.Join(names, ",")
+	}
+	return strings.Join(names[:maxLoggedNames], ",") + ",..."
+}
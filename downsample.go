@@ -0,0 +1,80 @@
+package influxdb
+
+import (
+	"math"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// gaugeStats aggregates the values of a gauge collected during an interval.
+type gaugeStats struct {
+	min, max, sum float64
+	n             int
+}
+
+func (s *gaugeStats) add(v float64) {
+	if s.n == 0 || v < s.min {
+		s.min = v
+	}
+	if s.n == 0 || v > s.max {
+		s.max = v
+	}
+	s.sum += v
+	s.n++
+}
+
+// collect records the current value of every gauge, at each sub interval.
+func (r *Reporter) collect() {
+	r.cfgMu.Lock()
+	defer r.cfgMu.Unlock()
+
+	r.each(func(_ *Source, name string, i interface{}) {
+		defer func() {
+			if v := recover(); v != nil {
+				r.panicked(v, name)
+			}
+		}()
+
+		if !r.keep(name, i) {
+			return
+		}
+
+		var v float64
+		switch m := i.(type) {
+		case metrics.Gauge:
+			v = float64(m.Value())
+		case metrics.GaugeFloat64:
+			v = m.Value()
+		default:
+			return
+		}
+		if math.IsNaN(v) {
+			return
+		}
+
+		s, ok := r.gaugeStats[name]
+		if !ok {
+			s = &gaugeStats{}
+			r.gaugeStats[name] = s
+		}
+		s.add(v)
+	})
+}
+
+// downsampledFields adds the min, max and average of the values collected since the last send
+// to the fields of a gauge, and starts a new interval.
+func (r *Reporter) downsampledFields(name string, fields map[string]interface{}) {
+	r.stateMu.Lock()
+	s, ok := r.gaugeStats[name]
+	if ok {
+		delete(r.gaugeStats, name)
+	}
+	r.stateMu.Unlock()
+	if !ok || s.n == 0 {
+		return
+	}
+
+	fields["min"] = s.min
+	fields["max"] = s.max
+	fields["avg"] = s.sum / float64(s.n)
+}
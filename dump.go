@@ -0,0 +1,94 @@
+package influxdb
+
+import (
+	"time"
+
+	"github.com/influxdata/influxdb/client"
+)
+
+// timedSample is implemented by samples which remember when each value was recorded.
+type timedSample interface {
+	TimedValues() ([]time.Time, []int64)
+}
+
+// TimedValues returns a copy of the values in the window and the times they were recorded at.
+func (s *SlidingWindowSample) TimedValues() ([]time.Time, []int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.trim(s.clock.Now())
+	times := make([]time.Time, s.n)
+	values := make([]int64, s.n)
+	for i := range values {
+		tv := s.at(i)
+		times[i], values[i] = tv.t, tv.v
+	}
+	return times, values
+}
+
+// DumpSamples writes every value of the sample of a histogram or sliding window timer as its own point,
+// at each send during d, to investigate suspicious percentiles. The points go to the measurement of the
+// metric suffixed with "samples", with a single value field.
+//
+// Values of sliding window samples keep the time they were recorded at. Other samples don't remember it,
+// so their values are written at the time of the send, one nanosecond apart.
+func (r *Reporter) DumpSamples(name string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dumps[name] = r.clock.Now().Add(d)
+}
+
+// dumping reports whether the samples of a metric must be dumped.
+func (r *Reporter) dumping(name string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	until, ok := r.dumps[name]
+	if !ok {
+		return false
+	}
+	if now.After(until) {
+		delete(r.dumps, name)
+		return false
+	}
+	return true
+}
+
+// samplePoints returns one point per value of the sample of a metric.
+func (r *Reporter) samplePoints(measurement string, tags map[string]string, typ string, i interface{}, now time.Time) []client.Point {
+	s, ok := i.(sampled)
+	if !ok {
+		return nil
+	}
+
+	unit := float64(1)
+	if typ == TypeTimer {
+		unit = float64(r.durationUnit.Nanoseconds())
+	}
+
+	var times []time.Time
+	var values []int64
+	if ts, ok := s.Sample().(timedSample); ok {
+		times, values = ts.TimedValues()
+	} else {
+		values = s.Sample().Values()
+	}
+
+	pts := make([]client.Point, len(values))
+	for j, v := range values {
+		t := now.Add(time.Duration(j))
+		if times != nil {
+			t = times[j]
+		}
+
+		pts[j] = client.Point{
+			Measurement: measurement + r.separator + "samples",
+			Tags:        tags,
+			Fields: map[string]interface{}{
+				"value": float64(v) / unit,
+			},
+			Time: t,
+		}
+	}
+	return pts
+}
@@ -0,0 +1,116 @@
+package influxdb
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// The environment variables read by ConfigFromEnv.
+const (
+	EnvURL      = "INFLUXDB_URL"
+	EnvDatabase = "INFLUXDB_DB"
+	EnvUsername = "INFLUXDB_USERNAME"
+	EnvPassword = "INFLUXDB_PASSWORD"
+	EnvToken    = "INFLUXDB_TOKEN"
+	EnvInterval = "REPORT_INTERVAL"
+	EnvTags     = "GLOBAL_TAGS"
+	EnvInclude  = "REPORT_INCLUDE"
+	EnvExclude  = "REPORT_EXCLUDE"
+)
+
+// defaultEnvInterval is the interval when REPORT_INTERVAL is not set.
+const defaultEnvInterval = 10 * time.Second
+
+// ConfigFromEnv reads a configuration from the environment:
+//
+//	INFLUXDB_URL       the InfluxDB url, http://localhost:8086 by default
+//	INFLUXDB_DB        the database
+//	INFLUXDB_USERNAME  the username
+//	INFLUXDB_PASSWORD  the password
+//	INFLUXDB_TOKEN     an InfluxDB 2 token, used as the password of the v1 compatibility API
+//	REPORT_INTERVAL    the interval, like 30s, 10s by default
+//	GLOBAL_TAGS        tags added to all the points, like service=api,env=prod
+//	REPORT_INCLUDE     comma-separated patterns of the metrics to report
+//	REPORT_EXCLUDE     comma-separated patterns of the metrics not to report
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		URL:      os.Getenv(EnvURL),
+		Database: os.Getenv(EnvDatabase),
+		Username: os.Getenv(EnvUsername),
+		Password: os.Getenv(EnvPassword),
+		Interval: defaultEnvInterval,
+		Include:  splitList(os.Getenv(EnvInclude)),
+		Exclude:  splitList(os.Getenv(EnvExclude)),
+	}
+	if cfg.URL == "" {
+		cfg.URL = "http://localhost:8086"
+	}
+
+	if token := os.Getenv(EnvToken); token != "" {
+		cfg.Password = token
+		if cfg.Username == "" {
+			cfg.Username = "token"
+		}
+	}
+
+	if s := os.Getenv(EnvInterval); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return Config{}, fmt.Errorf("unable to parse %s %q. err=%v", EnvInterval, s, err)
+		}
+		cfg.Interval = d
+	}
+
+	if s := os.Getenv(EnvTags); s != "" {
+		cfg.Tags = make(map[string]string)
+		for _, kv := range splitList(s) {
+			i := strings.IndexByte(kv, '=')
+			if i <= 0 {
+				return Config{}, fmt.Errorf("invalid tag %q in %s, expected key=value", kv, EnvTags)
+			}
+			cfg.Tags[strings.TrimSpace(kv[:i])] = strings.TrimSpace(kv[i+1:])
+		}
+	}
+
+	return cfg, nil
+}
+
+// NewFromEnv creates a reporter configured by the environment, as read by ConfigFromEnv.
+// The options are applied after the configuration.
+func NewFromEnv(r metrics.Registry, opts ...Option) (*Reporter, error) {
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewWithConfig(r, cfg, opts...)
+}
+
+// NewWithConfig creates a reporter from a configuration, which must be valid. The options are applied after the configuration.
+func NewWithConfig(r metrics.Registry, cfg Config, opts ...Option) (*Reporter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	cfgOpts := []Option{
+		WithTags(cfg.Tags),
+		WithInclude(cfg.Include...),
+		WithExclude(cfg.Exclude...),
+		WithRoutes(cfg.Routes...),
+	}
+	return New(r, cfg.Interval, cfg.URL, cfg.Database, cfg.Username, cfg.Password, append(cfgOpts, opts...)...)
+}
+
+// splitList splits a comma-separated list, dropping the empty items.
+func splitList(s string) []string {
+	var res []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
+		}
+	}
+	return res
+}
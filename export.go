@@ -0,0 +1,51 @@
+package influxdb
+
+import (
+	"sort"
+)
+
+// Snapshot returns the points the reporter would write now, of all the databases, without writing them.
+// It updates the state of the metrics like a send, so that the next snapshot or send only holds the changes,
+// which makes it a building block for tests and custom delivery paths. It must not be called while Run is running.
+func (r *Reporter) Snapshot() ([]Point, error) {
+	r.runCollectors()
+
+	r.cfgMu.Lock()
+	j, err := r.points()
+	r.cfgMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	defer release(j)
+	clearAll(j.cleared)
+
+	dbs := make([]string, 0, len(j.bs))
+	for db := range j.bs {
+		dbs = append(dbs, db)
+	}
+	sort.Strings(dbs)
+
+	var res []Point
+	for _, db := range dbs {
+		for _, pt := range j.bs[db] {
+			// the field maps are recycled with the job
+			fields := make(map[string]interface{}, len(pt.Fields))
+			for k, v := range pt.Fields {
+				fields[k] = v
+			}
+			pt.Fields = fields
+			res = append(res, pt)
+		}
+	}
+	return res, nil
+}
+
+// SnapshotLines returns the points of Snapshot encoded in the line protocol, with nanosecond timestamps.
+func (r *Reporter) SnapshotLines() ([]byte, error) {
+	pts, err := r.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	return AppendLines(nil, pts), nil
+}
@@ -0,0 +1,38 @@
+package influxdb
+
+import (
+	"expvar"
+	"fmt"
+)
+
+// publish publishes the status of the reporter under the expvar name.
+func (r *Reporter) publish(name string) error {
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("expvar %s is already published", name)
+	}
+
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		st := r.Status()
+		lastError := ""
+		if st.LastError != nil {
+			lastError = st.LastError.Error()
+		}
+		return map[string]interface{}{
+			"last_success":    st.LastSuccess,
+			"last_points":     st.LastPoints,
+			"last_error":      lastError,
+			"last_error_time": st.LastErrorTime,
+			"failures":        st.Failures,
+			"queued":          st.Queued,
+			"buffered":        st.Buffered,
+			"written":         st.Written,
+			"errors":          st.Errors,
+			"skipped":         st.Skipped,
+			"backed_off":      st.BackedOff,
+			"filtered":        st.Filtered,
+			"dropped":         st.Dropped,
+			"state":           st.State.String(),
+		}
+	}))
+	return nil
+}
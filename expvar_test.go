@@ -0,0 +1,42 @@
+package influxdb
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// expvars numbers the expvar names of the tests, which can't be published twice.
+var expvars atomic.Int64
+
+func TestExpvar(t *testing.T) {
+	name := fmt.Sprintf("influxdb_reporter_test_%d", expvars.Add(1))
+	influx := newFakeInflux(t)
+	r := newTestReporter(t, influx.URL, metrics.NewRegistry(), WithExpvar(name))
+	r.WritePoints(Point{Measurement: "deploy", Fields: map[string]interface{}{"value": int64(1)}})
+
+	influx.failing("db", true)
+	if err := r.Send(); err == nil {
+		t.Fatal("the send succeeded, want it to fail")
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &got); err != nil {
+		t.Fatalf("unable to decode the expvar. err=%v", err)
+	}
+	st := r.Status()
+	for k, want := range map[string]interface{}{
+		"state":      st.State.String(),
+		"failures":   float64(st.Failures),
+		"errors":     float64(st.Errors),
+		"last_error": st.LastError.Error(),
+	} {
+		if got[k] != want {
+			t.Errorf("%s is %v, want %v", k, got[k], want)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package influxdb
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// Metric types as reported by the reporter.
+const (
+	TypeCounter     = "counter"
+	TypeGauge       = "gauge"
+	TypeHistogram   = "histogram"
+	TypeMeter       = "meter"
+	TypeTimer       = "timer"
+	TypeHealthcheck = "healthcheck"
+	TypeEWMA        = "ewma"
+)
+
+var metricTypes = []string{TypeCounter, TypeGauge, TypeHistogram, TypeMeter, TypeTimer, TypeHealthcheck, TypeEWMA}
+
+var defaultPercentiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999, 0.9999}
+
+// percentileName returns the field name of a percentile: p50 for 0.5, p999 for 0.999.
+func percentileName(p float64) string {
+	if p >= 1 {
+		return "p100"
+	}
+
+	s := strings.TrimPrefix(strconv.FormatFloat(p, 'f', -1, 64), "0.")
+	if len(s) == 1 {
+		s += "0"
+	}
+	return "p" + s
+}
+
+func percentileNames(ps []float64) []string {
+	names := make([]string, len(ps))
+	for i, p := range ps {
+		names[i] = percentileName(p)
+	}
+	return names
+}
+
+// unitName returns the short name of a duration unit, like ms or us.
+func unitName(d time.Duration) string {
+	switch d {
+	case time.Nanosecond:
+		return "ns"
+	case time.Microsecond:
+		return "us"
+	case time.Millisecond:
+		return "ms"
+	case time.Second:
+		return "s"
+	case time.Minute:
+		return "m"
+	case time.Hour:
+		return "h"
+	}
+	return d.String()
+}
+
+// metricType returns the metric type of a registry entry, or an empty string if it is not supported.
+func metricType(i interface{}) string {
+	switch m := i.(type) {
+	case metrics.Counter:
+		return TypeCounter
+	case metrics.Gauge, metrics.GaugeFloat64, *BoolGauge, *StringGauge:
+		return TypeGauge
+	case metrics.Histogram:
+		return TypeHistogram
+	case metrics.Meter:
+		return TypeMeter
+	case metrics.Timer:
+		return TypeTimer
+	case *HDR:
+		if m.timer {
+			return TypeTimer
+		}
+		return TypeHistogram
+	case metrics.Healthcheck:
+		return TypeHealthcheck
+	case metrics.EWMA:
+		return TypeEWMA
+	case resettingTimer:
+		return TypeTimer
+	}
+	return ""
+}
+
+// fields returns the metric type, the fields to write for a registry entry and,
+// for histograms and timers, the values of the configured percentiles.
+// The fields are nil if the metric is not supported.
+func (r *Reporter) fields(name string, i interface{}) (string, map[string]interface{}, []float64) {
+	// go-metrics rates are per second
+	rate := r.rateUnit.Seconds()
+
+	switch m := i.(type) {
+	case metrics.Counter:
+		fields := newFields()
+		fields["value"] = m.Count()
+		return TypeCounter, fields, nil
+	case metrics.Gauge:
+		fields := newFields()
+		fields["value"] = m.Value()
+		return TypeGauge, fields, nil
+	case metrics.GaugeFloat64:
+		fields := newFields()
+		fields["value"] = m.Value()
+		return TypeGauge, fields, nil
+	case *BoolGauge:
+		fields := newFields()
+		fields["value"] = m.Value()
+		return TypeGauge, fields, nil
+	case *StringGauge:
+		fields := newFields()
+		fields["value"] = m.Value()
+		return TypeGauge, fields, nil
+	case metrics.Histogram:
+		fields := newFields()
+		fields["count"] = m.Count()
+		fields["max"] = m.Max()
+		fields["mean"] = m.Mean()
+		fields["min"] = m.Min()
+		fields["stddev"] = m.StdDev()
+		fields["variance"] = m.Variance()
+		return TypeHistogram, fields, m.Percentiles(r.percentiles)
+	case metrics.Meter:
+		fields := newFields()
+		fields["count"] = m.Count()
+		fields["m1"] = m.Rate1() * rate
+		fields["m5"] = m.Rate5() * rate
+		fields["m15"] = m.Rate15() * rate
+		fields["mean"] = m.RateMean() * rate
+		return TypeMeter, fields, nil
+	case metrics.Timer:
+		unit := r.durationUnit.Nanoseconds()
+		fields := newFields()
+		fields["count"] = m.Count()
+		fields["max"] = m.Max() / unit
+		fields["mean"] = m.Mean() / float64(unit)
+		fields["min"] = m.Min() / unit
+		fields["stddev"] = m.StdDev() / float64(unit)
+		fields["variance"] = m.Variance() / (float64(unit) * float64(unit))
+		fields["m1"] = m.Rate1() * rate
+		fields["m5"] = m.Rate5() * rate
+		fields["m15"] = m.Rate15() * rate
+		fields["meanrate"] = m.RateMean() * rate
+		if r.durationUnitField {
+			fields["unit"] = unitName(r.durationUnit)
+		}
+		r.sloFields(name, m, fields)
+		ps := m.Percentiles(r.percentiles)
+		for i := range ps {
+			ps[i] /= float64(unit)
+		}
+		return TypeTimer, fields, ps
+	case *HDR:
+		return r.hdrFields(m)
+	case metrics.Healthcheck:
+		m.Check()
+		fields := newFields()
+		fields["healthy"] = m.Error() == nil
+		if err := m.Error(); err != nil {
+			fields["error"] = err.Error()
+		}
+		return TypeHealthcheck, fields, nil
+	case metrics.EWMA:
+		fields := newFields()
+		fields["rate"] = m.Rate() * rate
+		return TypeEWMA, fields, nil
+	case resettingTimer:
+		return r.resettingFields(m)
+	}
+
+	return "", nil, nil
+}
+
+// toFloat converts every integer field of a metric of the given type to a float64,
+// except the ones kept as integers by keep.
+func toFloat(typ string, fields map[string]interface{}, keep map[string]bool) {
+	if keep[typ] {
+		return
+	}
+
+	for k, v := range fields {
+		if keep[typ+"."+k] {
+			continue
+		}
+
+		switch n := v.(type) {
+		case int:
+			fields[k] = float64(n)
+		case int32:
+			fields[k] = float64(n)
+		case int64:
+			fields[k] = float64(n)
+		case uint64:
+			fields[k] = float64(n)
+		}
+	}
+}
+
+// FieldNamer returns the key written for a field of a metric of the given type.
+type FieldNamer func(metricType, field string) string
+
+// FieldNames returns a FieldNamer which renames fields using a map. A key is either a field,
+// like "m1", or a metric type and a field, like "timer.m1", which takes precedence.
+// Fields missing from the map keep their name.
+func FieldNames(names map[string]string) FieldNamer {
+	return func(metricType, field string) string {
+		if n, ok := names[metricType+"."+field]; ok {
+			return n
+		}
+		if n, ok := names[field]; ok {
+			return n
+		}
+		return field
+	}
+}
+
+func (r *Reporter) renameFields(typ string, fields map[string]interface{}) map[string]interface{} {
+	if r.fieldNamer == nil {
+		return fields
+	}
+
+	res := newFields()
+	for k, v := range fields {
+		res[r.fieldNamer(typ, k)] = v
+	}
+	putFields(fields)
+	return res
+}
+
+// delta returns the change of a counter since the last call. A counter which went down,
+// because it was cleared, counts from zero.
+func (r *Reporter) delta(name string, count int64) int64 {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	last, ok := r.lastCounters[name]
+	r.lastCounters[name] = count
+	if !ok || count < last {
+		return count
+	}
+	return count - last
+}
@@ -0,0 +1,78 @@
+package influxdb
+
+import (
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestCounterDeltas(t *testing.T) {
+	tests := []struct {
+		name       string
+		opt        Option
+		incs       []int64
+		clear      int
+		wantValues []int64
+		wantField  string
+		wantOthers []int64
+	}{
+		{
+			name:       "deltas",
+			opt:        WithCounterDeltas(false),
+			incs:       []int64{3, 0, 2},
+			clear:      -1,
+			wantValues: []int64{3, 0, 2},
+		},
+		{
+			name:       "deltas with the cumulative count",
+			opt:        WithCounterDeltas(true),
+			incs:       []int64{3, 2},
+			clear:      -1,
+			wantValues: []int64{3, 2},
+			wantField:  "count",
+			wantOthers: []int64{3, 5},
+		},
+		{
+			name:       "delta field",
+			opt:        WithCounterDeltaField(),
+			incs:       []int64{3, 2},
+			clear:      -1,
+			wantValues: []int64{3, 5},
+			wantField:  "delta",
+			wantOthers: []int64{3, 2},
+		},
+		{
+			// a counter cleared by someone else starts over instead of going negative
+			name:       "cleared",
+			opt:        WithCounterDeltas(false),
+			incs:       []int64{3, 1},
+			clear:      1,
+			wantValues: []int64{3, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := metrics.NewRegistry()
+			c := metrics.GetOrRegisterCounter("requests", reg)
+			r := newTestReporter(t, unreachable, reg, tt.opt)
+
+			for i, n := range tt.incs {
+				if i == tt.clear {
+					c.Clear()
+				}
+				c.Inc(n)
+				fields := snapshotPoints(t, r)["requests.count"].Fields
+				if got := fields["value"]; got != tt.wantValues[i] {
+					t.Errorf("send %d: value is %v, want %d", i, got, tt.wantValues[i])
+				}
+				if tt.wantField == "" {
+					continue
+				}
+				if got := fields[tt.wantField]; got != tt.wantOthers[i] {
+					t.Errorf("send %d: %s is %v, want %d", i, tt.wantField, got, tt.wantOthers[i])
+				}
+			}
+		})
+	}
+}
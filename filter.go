@@ -0,0 +1,142 @@
+package influxdb
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// Filter reports whether a registry entry must be reported.
+type Filter func(name string, metric interface{}) bool
+
+// SkipPolicy tells the reporter which meters, timers and histograms to skip.
+type SkipPolicy int
+
+const (
+	// SkipNone reports every metric.
+	SkipNone SkipPolicy = iota
+	// SkipZeroCount skips the metrics which have never been updated.
+	SkipZeroCount
+	// SkipUnchangedCount skips the metrics whose count didn't change since the last send,
+	// including those which have never been updated.
+	SkipUnchangedCount
+)
+
+type counted interface {
+	Count() int64
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metric filter %q. err=%v", p, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+func matchAny(res []*regexp.Regexp, name string) bool {
+	for _, re := range res {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// keep reports whether a registry entry must be reported.
+func (r *Reporter) keep(name string, i interface{}) bool {
+	if len(r.include) > 0 && !matchAny(r.include, name) {
+		return false
+	}
+	if matchAny(r.exclude, name) {
+		return false
+	}
+	if r.disabledTypes[metricType(i)] {
+		return false
+	}
+	if r.filter != nil && !r.filter(name, i) {
+		return false
+	}
+	return true
+}
+
+// lastValue is the value of a counter or gauge at the last time it was reported.
+type lastValue struct {
+	value   interface{}
+	skipped int
+}
+
+// gaugeValue returns the current value of a counter or gauge.
+func gaugeValue(i interface{}) (interface{}, bool) {
+	switch m := i.(type) {
+	case metrics.Counter:
+		return m.Count(), true
+	case metrics.Gauge:
+		return m.Value(), true
+	case metrics.GaugeFloat64:
+		return m.Value(), true
+	case *BoolGauge:
+		return m.Value(), true
+	case *StringGauge:
+		return m.Value(), true
+	}
+	return nil, false
+}
+
+// skip reports whether a registry entry must be skipped because it didn't change.
+func (r *Reporter) skip(name string, i interface{}) bool {
+	if r.onlyChanged {
+		if v, ok := gaugeValue(i); ok {
+			return r.unchanged(name, v)
+		}
+	}
+
+	if r.skipPolicy == SkipNone {
+		return false
+	}
+
+	switch i.(type) {
+	case metrics.Histogram, metrics.Meter, metrics.Timer:
+	default:
+		return false
+	}
+
+	count := i.(counted).Count()
+
+	switch r.skipPolicy {
+	case SkipZeroCount:
+		return count == 0
+	case SkipUnchangedCount:
+		last := r.lastCounts[name]
+		r.lastCounts[name] = count
+		return count == last
+	}
+	return false
+}
+
+// unchanged reports whether a counter or gauge has the same value as the last time it was reported,
+// and no heartbeat is due.
+func (r *Reporter) unchanged(name string, v interface{}) bool {
+	last, ok := r.lastValues[name]
+	if ok && last.value == v && (r.heartbeat <= 0 || last.skipped+1 < r.heartbeat) {
+		last.skipped++
+		return true
+	}
+
+	r.lastValues[name] = &lastValue{value: v}
+	return false
+}
+
+// due reports whether a metric is reported by the current send, given the interval of its type.
+func (r *Reporter) due(i interface{}) bool {
+	n, ok := r.typeEvery[metricType(i)]
+	if !ok {
+		return true
+	}
+	return (r.sends-1)%n == 0
+}
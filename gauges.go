@@ -0,0 +1,73 @@
+package influxdb
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// BoolGauge holds a boolean value, written as a boolean field.
+type BoolGauge struct {
+	value int32
+}
+
+// NewBoolGauge constructs a new BoolGauge.
+func NewBoolGauge() *BoolGauge {
+	return &BoolGauge{}
+}
+
+// GetOrRegisterBoolGauge returns an existing BoolGauge or constructs and registers a new one.
+func GetOrRegisterBoolGauge(name string, r metrics.Registry) *BoolGauge {
+	if r == nil {
+		r = metrics.DefaultRegistry
+	}
+	return r.GetOrRegister(name, NewBoolGauge).(*BoolGauge)
+}
+
+// Update sets the value of the gauge.
+func (g *BoolGauge) Update(v bool) {
+	var i int32
+	if v {
+		i = 1
+	}
+	atomic.StoreInt32(&g.value, i)
+}
+
+// Value returns the value of the gauge.
+func (g *BoolGauge) Value() bool {
+	return atomic.LoadInt32(&g.value) == 1
+}
+
+// StringGauge holds a short string value, like the current leader or a git SHA, written as a string field.
+type StringGauge struct {
+	mu    sync.RWMutex
+	value string
+}
+
+// NewStringGauge constructs a new StringGauge.
+func NewStringGauge() *StringGauge {
+	return &StringGauge{}
+}
+
+// GetOrRegisterStringGauge returns an existing StringGauge or constructs and registers a new one.
+func GetOrRegisterStringGauge(name string, r metrics.Registry) *StringGauge {
+	if r == nil {
+		r = metrics.DefaultRegistry
+	}
+	return r.GetOrRegister(name, NewStringGauge).(*StringGauge)
+}
+
+// Update sets the value of the gauge.
+func (g *StringGauge) Update(v string) {
+	g.mu.Lock()
+	g.value = v
+	g.mu.Unlock()
+}
+
+// Value returns the value of the gauge.
+func (g *StringGauge) Value() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.value
+}
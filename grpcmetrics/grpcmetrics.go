@@ -0,0 +1,81 @@
+// Package grpcmetrics provides gRPC interceptors recording per-method metrics into a go-metrics registry,
+// following the naming conventions of the InfluxDB reporter.
+//
+// For each method, like /helloworld.Greeter/SayHello, the interceptors maintain a
+// <prefix>.helloworld.Greeter.SayHello.requests counter, a .errors counter and a .latency timer,
+// where prefix is grpc.server or grpc.client.
+package grpcmetrics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+	"google.golang.org/grpc"
+)
+
+const (
+	serverPrefix = "grpc.server"
+	clientPrefix = "grpc.client"
+)
+
+// methodName turns a full method name, like /helloworld.Greeter/SayHello, into helloworld.Greeter.SayHello.
+func methodName(fullMethod string) string {
+	return strings.Replace(strings.TrimPrefix(fullMethod, "/"), "/", ".", -1)
+}
+
+// record updates the metrics of a call.
+func record(reg metrics.Registry, prefix, fullMethod string, start time.Time, err error) {
+	if reg == nil {
+		reg = metrics.DefaultRegistry
+	}
+
+	name := prefix + "." + methodName(fullMethod)
+
+	metrics.GetOrRegisterCounter(name+".requests", reg).Inc(1)
+	if err != nil {
+		metrics.GetOrRegisterCounter(name+".errors", reg).Inc(1)
+	}
+	metrics.GetOrRegisterTimer(name+".latency", reg).UpdateSince(start)
+}
+
+// UnaryServerInterceptor records the metrics of the unary calls served.
+func UnaryServerInterceptor(reg metrics.Registry) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		record(reg, serverPrefix, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor records the metrics of the streams served. The latency is the duration of the stream.
+func StreamServerInterceptor(reg metrics.Registry) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		record(reg, serverPrefix, info.FullMethod, start, err)
+		return err
+	}
+}
+
+// UnaryClientInterceptor records the metrics of the unary calls made.
+func UnaryClientInterceptor(reg metrics.Registry) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		record(reg, clientPrefix, method, start, err)
+		return err
+	}
+}
+
+// StreamClientInterceptor records the metrics of the streams opened. The latency is the time taken to open the stream.
+func StreamClientInterceptor(reg metrics.Registry) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		record(reg, clientPrefix, method, start, err)
+		return cs, err
+	}
+}
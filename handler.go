@@ -0,0 +1,48 @@
+package influxdb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/client"
+)
+
+// Point is a point written to InfluxDB.
+type Point = client.Point
+
+// TypeHandler returns the points of a registry entry, or false to let the reporter handle it.
+type TypeHandler func(name string, metric interface{}) ([]Point, bool)
+
+var (
+	handlersMu sync.RWMutex
+	handlers   []TypeHandler
+)
+
+// RegisterTypeHandler teaches every reporter how to write a metric type it doesn't know about,
+// or to write a known type differently. Handlers are called in the order they were registered,
+// before the built-in types. Points without a time get the time of the send.
+func RegisterTypeHandler(h TypeHandler) {
+	handlersMu.Lock()
+	handlers = append(handlers, h)
+	handlersMu.Unlock()
+}
+
+// handle returns the points of the first handler accepting a registry entry.
+func handle(name string, i interface{}, now time.Time) ([]Point, bool) {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+
+	for _, h := range handlers {
+		pts, ok := h(name, i)
+		if !ok {
+			continue
+		}
+		for j := range pts {
+			if pts[j].Time.IsZero() {
+				pts[j].Time = now
+			}
+		}
+		return pts, true
+	}
+	return nil, false
+}
@@ -0,0 +1,81 @@
+package influxdb
+
+import (
+	"sync"
+	"time"
+)
+
+// HDRHistogram is the subset of the github.com/HdrHistogram/hdrhistogram-go histogram used by the reporter,
+// so that any HDR histogram implementation can be adapted.
+type HDRHistogram interface {
+	RecordValue(v int64) error
+	TotalCount() int64
+	Min() int64
+	Max() int64
+	Mean() float64
+	StdDev() float64
+	// ValueAtQuantile returns the value at a quantile between 0 and 100.
+	ValueAtQuantile(q float64) int64
+}
+
+// HDR wraps an HDR histogram so it can be registered in a registry. Its percentiles are
+// much more accurate than those of go-metrics samples, which underestimate tail latencies under bursty load.
+type HDR struct {
+	mu    sync.Mutex
+	h     HDRHistogram
+	timer bool
+}
+
+// NewHDRHistogram wraps an HDR histogram, reported like a histogram.
+func NewHDRHistogram(h HDRHistogram) *HDR {
+	return &HDR{h: h}
+}
+
+// NewHDRTimer wraps an HDR histogram holding durations in nanoseconds, reported like a timer.
+func NewHDRTimer(h HDRHistogram) *HDR {
+	return &HDR{h: h, timer: true}
+}
+
+// Update records a value. Values out of the range of the histogram are ignored.
+func (h *HDR) Update(v int64) {
+	h.mu.Lock()
+	_ = h.h.RecordValue(v)
+	h.mu.Unlock()
+}
+
+// UpdateDuration records a duration.
+func (h *HDR) UpdateDuration(d time.Duration) {
+	h.Update(d.Nanoseconds())
+}
+
+// UpdateSince records the duration elapsed since t.
+func (h *HDR) UpdateSince(t time.Time) {
+	h.UpdateDuration(time.Since(t))
+}
+
+func (r *Reporter) hdrFields(h *HDR) (string, map[string]interface{}, []float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	unit := float64(1)
+	typ := TypeHistogram
+	if h.timer {
+		unit = float64(r.durationUnit.Nanoseconds())
+		typ = TypeTimer
+	}
+
+	fields := map[string]interface{}{
+		"count":  h.h.TotalCount(),
+		"max":    float64(h.h.Max()) / unit,
+		"mean":   h.h.Mean() / unit,
+		"min":    float64(h.h.Min()) / unit,
+		"stddev": h.h.StdDev() / unit,
+	}
+
+	ps := make([]float64, len(r.percentiles))
+	for i, p := range r.percentiles {
+		ps[i] = float64(h.h.ValueAtQuantile(p*100)) / unit
+	}
+
+	return typ, fields, ps
+}
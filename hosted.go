@@ -0,0 +1,29 @@
+package influxdb
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// NewHostedClient creates a client for a hosted InfluxDB compatible endpoint, like Grafana Cloud, which
+// authenticates with a numeric user ID and an API key, requires HTTPS and accepts gzip. The url includes
+// the path before /write, like https://influx-prod-01.grafana.net/api/v1/push/influx, or the organization
+// prefix of the provider. Such endpoints don't implement /ping, so the client doesn't ping.
+func NewHostedClient(url, userID, apiKey string, opts ...ClientOption) (*Client, error) {
+	u, err := parseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse InfluxDB url %s. err=%v", url, err)
+	}
+	if u.Scheme != "https" {
+		return nil, fmt.Errorf("hosted InfluxDB url %s must use https", url)
+	}
+	if _, err := strconv.ParseUint(userID, 10, 64); err != nil {
+		return nil, fmt.Errorf("hosted InfluxDB user ID %q must be numeric", userID)
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("empty hosted InfluxDB API key")
+	}
+
+	opts = append([]ClientOption{WithGzip(), WithPingInterval(0)}, opts...)
+	return NewClient(url, userID, apiKey, opts...)
+}
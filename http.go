@@ -0,0 +1,63 @@
+package influxdb
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// statusRecorder remembers the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher if the wrapped writer does.
+func (w *statusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// HTTPMiddleware records metrics about the requests served by a handler into the registry:
+// a <name>.requests counter, a <name>.status.<class> counter per status class, like <name>.status.2xx,
+// and a <name>.latency timer. Use one name per route and a template like "http.route.measurement"
+// to turn them into tags.
+func HTTPMiddleware(reg metrics.Registry, name string, next http.Handler) http.Handler {
+	if reg == nil {
+		reg = metrics.DefaultRegistry
+	}
+
+	requests := metrics.GetOrRegisterCounter(name+".requests", reg)
+	latency := metrics.GetOrRegisterTimer(name+".latency", reg)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(rec, req)
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		requests.Inc(1)
+		metrics.GetOrRegisterCounter(name+".status."+strconv.Itoa(status/100)+"xx", reg).Inc(1)
+		latency.UpdateSince(start)
+	})
+}
@@ -0,0 +1,43 @@
+package influxdb
+
+// activity returns what tells whether a metric was active since the last send: the count of the metrics
+// counting events, the value of the counters and gauges. The other metrics never count as activity.
+func activity(i interface{}) (interface{}, bool) {
+	if v, ok := gaugeValue(i); ok {
+		return v, true
+	}
+	if c, ok := i.(counted); ok {
+		return c.Count(), true
+	}
+	return nil, false
+}
+
+// idle reports whether the metric points of a send must be skipped because none of its entries changed
+// since the last send and no points are pending, unless maxIdleSkips sends were skipped in a row already.
+func (r *Reporter) idle(es []entry) bool {
+	active := r.hasPending()
+	for _, e := range es {
+		if !e.hasActivity {
+			continue
+		}
+		if last, seen := r.idleValues[e.name]; !seen || last != e.activity {
+			r.idleValues[e.name] = e.activity
+			active = true
+		}
+	}
+
+	if active || r.idleSkips >= r.maxIdleSkips {
+		r.idleSkips = 0
+		return false
+	}
+	r.idleSkips++
+	r.logger.logf(LogDebug, "skipping the metrics of an idle send to InfluxDB. skipped=%d", r.idleSkips)
+	return true
+}
+
+// hasPending reports whether points given to WritePoints wait for the next send.
+func (r *Reporter) hasPending() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.pending) > 0
+}
@@ -0,0 +1,67 @@
+package influxdb
+
+import (
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestIdleSuppression(t *testing.T) {
+	type send struct {
+		inc         int64
+		write       bool
+		wantMetrics bool
+	}
+	tests := []struct {
+		name     string
+		maxSkips int
+		sends    []send
+	}{
+		{
+			name:     "skips the idle sends",
+			maxSkips: 2,
+			sends: []send{
+				{inc: 1, wantMetrics: true},
+				{wantMetrics: false},
+				{wantMetrics: false},
+				{wantMetrics: true},
+				{wantMetrics: false},
+				{inc: 1, wantMetrics: true},
+			},
+		},
+		{
+			name:     "pending points",
+			maxSkips: 5,
+			sends: []send{
+				{inc: 1, wantMetrics: true},
+				{write: true, wantMetrics: true},
+				{wantMetrics: false},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := metrics.NewRegistry()
+			c := metrics.GetOrRegisterCounter("requests", reg)
+			r := newTestReporter(t, unreachable, reg, WithIdleSuppression(tt.maxSkips), WithHeartbeat())
+
+			for i, s := range tt.sends {
+				c.Inc(s.inc)
+				if s.write {
+					r.WritePoints(Point{Measurement: "adhoc", Fields: map[string]interface{}{"value": int64(1)}})
+				}
+				pts := snapshotPoints(t, r)
+				if _, ok := pts["requests.count"]; ok != s.wantMetrics {
+					t.Errorf("send %d: metric points %v, want %v", i, ok, s.wantMetrics)
+				}
+				if _, ok := pts["heartbeat"]; !ok {
+					t.Errorf("send %d: no heartbeat", i)
+				}
+				if _, ok := pts["adhoc"]; ok != s.write {
+					t.Errorf("send %d: pending points %v, want %v", i, ok, s.write)
+				}
+			}
+		})
+	}
+}
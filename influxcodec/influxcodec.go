@@ -0,0 +1,53 @@
+// Package influxcodec provides zstd and snappy codecs to compress the writes of an InfluxDB client,
+// for the ingestion gateways and Telegraf inputs which accept them:
+//
+//	c, err := influxdb.NewClient(url, username, password, influxdb.WithCodec(influxcodec.Zstd))
+package influxcodec
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	influxdb "github.com/vrischmann/go-metrics-influxdb"
+)
+
+// Zstd compresses with zstd, at the default level.
+var Zstd influxdb.Codec = zstdCodec{}
+
+// Snappy compresses with the block format of snappy, like the Prometheus remote write protocol.
+var Snappy influxdb.Codec = snappyCodec{}
+
+type zstdCodec struct{}
+
+var zstdPool = sync.Pool{
+	New: func() interface{} {
+		// only fails on invalid options
+		w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return w
+	},
+}
+
+func (zstdCodec) Encoding() string {
+	return "zstd"
+}
+
+func (zstdCodec) Compress(dst *bytes.Buffer, data []byte) error {
+	w := zstdPool.Get().(*zstd.Encoder)
+	defer zstdPool.Put(w)
+
+	dst.Write(w.EncodeAll(data, dst.AvailableBuffer()))
+	return nil
+}
+
+type snappyCodec struct{}
+
+func (snappyCodec) Encoding() string {
+	return "snappy"
+}
+
+func (snappyCodec) Compress(dst *bytes.Buffer, data []byte) error {
+	dst.Write(snappy.Encode(nil, data))
+	return nil
+}
@@ -0,0 +1,55 @@
+package influxcodec
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	influxdb "github.com/vrischmann/go-metrics-influxdb"
+)
+
+func TestCodecs(t *testing.T) {
+	zr, err := zstd.NewReader(nil)
+	if err != nil {
+		t.Fatalf("unable to create zstd reader. err=%v", err)
+	}
+	defer zr.Close()
+
+	tests := []struct {
+		codec      influxdb.Codec
+		encoding   string
+		decompress func([]byte) ([]byte, error)
+	}{
+		{Zstd, "zstd", func(b []byte) ([]byte, error) { return zr.DecodeAll(b, nil) }},
+		{Snappy, "snappy", func(b []byte) ([]byte, error) { return snappy.Decode(nil, b) }},
+	}
+
+	data := []byte(strings.Repeat("requests.count,host=a value=1i 1577836800000000000\n", 100))
+	for _, tt := range tests {
+		if got := tt.codec.Encoding(); got != tt.encoding {
+			t.Errorf("the encoding is %q, want %q", got, tt.encoding)
+		}
+
+		// the compressed body is appended to what the buffer holds
+		dst := bytes.NewBufferString("prefix")
+		if err := tt.codec.Compress(dst, data); err != nil {
+			t.Fatalf("%s: unable to compress. err=%v", tt.encoding, err)
+		}
+		if !bytes.HasPrefix(dst.Bytes(), []byte("prefix")) {
+			t.Fatalf("%s: the buffer lost its content", tt.encoding)
+		}
+		compressed := dst.Bytes()[len("prefix"):]
+		if len(compressed) >= len(data) {
+			t.Errorf("%s: compressed %d bytes to %d", tt.encoding, len(data), len(compressed))
+		}
+		got, err := tt.decompress(compressed)
+		if err != nil {
+			t.Fatalf("%s: unable to decompress. err=%v", tt.encoding, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("%s: got %q after a round trip", tt.encoding, got)
+		}
+	}
+}
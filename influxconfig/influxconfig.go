@@ -0,0 +1,52 @@
+// Package influxconfig loads the configuration of an InfluxDB reporter from a YAML or TOML file,
+// so that the reporting can be changed without changing the code.
+//
+// A YAML file looks like:
+//
+//	url: http://localhost:8086
+//	database: metrics
+//	interval: 10s
+//	tags:
+//	  service: api
+//	exclude:
+//	  - ^debug\.
+//	routes:
+//	  - pattern: ^business\.
+//	    database: kpis
+//
+// and the TOML file with the same keys.
+package influxconfig
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+	influxdb "github.com/vrischmann/go-metrics-influxdb"
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads a configuration from a file, in YAML if its extension is .yaml or .yml,
+// and in TOML if it is .toml.
+func Load(path string) (influxdb.Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return influxdb.Config{}, fmt.Errorf("unable to read configuration %s. err=%v", path, err)
+	}
+
+	var cfg influxdb.Config
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &cfg)
+	case ".toml":
+		err = toml.Unmarshal(data, &cfg)
+	default:
+		return influxdb.Config{}, fmt.Errorf("unknown configuration format %s, expected .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return influxdb.Config{}, fmt.Errorf("unable to parse configuration %s. err=%v", path, err)
+	}
+
+	return cfg, nil
+}
@@ -1,198 +1,968 @@
 package influxdb
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
-	uurl "net/url"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"sync"
 	"time"
 
 	"os"
+	"os/signal"
+	"runtime/pprof"
 
 	"github.com/influxdata/influxdb/client"
 	"github.com/rcrowley/go-metrics"
 )
 
-type reporter struct {
-	reg      metrics.Registry
-	interval time.Duration
+// Reporter posts the metrics of a registry to InfluxDB at a fixed interval.
+type Reporter struct {
+	mu sync.Mutex
+	// cfgMu guards the configuration changed by Update during sends
+	cfgMu sync.Mutex
 
-	tagHost bool
+	sources      []*Source
+	interval     time.Duration
+	intervals    chan time.Duration
+	aligned      bool
+	jitter       bool
+	immediate    bool
+	warmup       time.Duration
+	flushes      chan struct{}
+	flushSignals []os.Signal
+	started      time.Time
+
+	// jitterFraction is the fraction of the interval the sends are delayed by, picked once
+	jitterFraction float64
+
+	tags      map[string]string
+	tagHost   bool
+	prefix    string
+	separator string
 
-	url      uurl.URL
 	database string
-	username string
-	password string
 
-	client *client.Client
+	sanitizer Sanitizer
+	nanPolicy NaNPolicy
+
+	floatFields   bool
+	integerFields map[string]bool
+
+	schema *schema
+
+	percentiles     []float64
+	percentileNames []string
+	quantilePoints  bool
+	quantiles       []string
+
+	bucketBounds []float64
+	bucketLabels []string
+	bucketPoints bool
+
+	durationUnit      time.Duration
+	durationUnitField bool
+	rateUnit          time.Duration
+
+	fieldNamer FieldNamer
+
+	counterDeltas     bool
+	deltaField        bool
+	counterCumulative bool
+	lastCounters      map[string]int64
+
+	clearOnFlush bool
+
+	windowed    bool
+	lastWindows map[string]window
+
+	dumps map[string]time.Time
+
+	sloThresholds map[string][]time.Duration
+
+	subInterval time.Duration
+	gaugeStats  map[string]*gaugeStats
+
+	flushInterval time.Duration
+	buffer        job
+
+	templateSpecs []string
+	templates     []*template
+
+	includePatterns []string
+	excludePatterns []string
+	include         []*regexp.Regexp
+	exclude         []*regexp.Regexp
+	filter          Filter
+	disabledTypes   map[string]bool
+	routeSpecs      []Route
+	routes          []route
+	typeIntervals   map[string]time.Duration
+	typeEvery       map[string]int64
+	tiers           []Tier
+	compiledTiers   []*tier
+	tierCache       map[string]*tier
+	sends           int64
+	skipPolicy      SkipPolicy
+	lastCounts      map[string]int64
+	onlyChanged     bool
+	heartbeat       int
+	lastValues      map[string]*lastValue
+
+	nameMapper   NameMapper
+	typeSuffixes map[string]string
+	typePrefixes map[string]string
+	typeTag      bool
+	seriesCache  sync.Map
+
+	collectors []Collector
+	runtime    bool
+
+	// extras return points which don't come from a registry, like build_info
+	extras []func(now time.Time) []Point
+
+	meta map[string]*meta
+	// pending are the points of WritePoints and Event, written with the next send
+	pending          []Point
+	eventMeasurement string
+
+	clock    Clock
+	truncate bool
+
+	unitTags        bool
+	units           map[string]string
+	unitConventions bool
+
+	inventory bool
+
+	client        *Client
+	ownClient     bool
+	clientOptions []ClientOption
+	logger        leveledLogger
+	errorHandler  func(error)
+	sendHook      func(SendInfo) func(SendInfo)
+	middlewares   []Middleware
+	selfPrefix    string
+	self          *selfMetrics
+	status        status
+	expvarName    string
+	healthName    string
+	lastBatches   lastBatches
+
+	connStateHandler func(from, to ConnState)
+
+	// failuresHandler is called after maxFailures consecutive failed sends
+	maxFailures     int
+	failuresHandler func(failures int, err error)
+
+	watchdog bool
+
+	shardClients []*Client
+	shardBy      string
+	shards       *shards
+
+	tenantTag    string
+	tenantPrefix string
+
+	sinks []Sink
+
+	// knownNames are the names of the metrics seen by the last send
+	registryDiff bool
+	diffHandler  func(RegistryDiff)
+	knownNames   map[string]struct{}
+
+	maintenance     maintenance
+	maintenanceMode MaintenanceMode
+
+	// intervalTags are the tags of the intervals of the points, reused from one send to the next
+	intervalTag   string
+	intervalField string
+	intervalTags  map[time.Duration]map[string]string
+
+	// idleValues are the activity of the metrics at the last send, and idleSkips the idle sends skipped in a row
+	maxIdleSkips int
+	idleValues   map[string]interface{}
+	idleSkips    int
+	// progress is the time the loop last handled an event, in nanoseconds, and watched the interval,
+	// read atomically by the watchdog
+	progress int64
+	watched  int64
+
+	startupCheck   bool
+	startupRetries int
+	startupBackoff time.Duration
+
+	databaseCheck  bool
+	createDatabase bool
+
+	maxInterval time.Duration
+	failures    int
+	backoff     int64
+
+	overlapPolicy OverlapPolicy
+	queueSize     int
+
+	workers  int
+	maxBatch int
+	pipe     *pipeline
+	// enc is only used by the writer goroutine
+	enc  encoder
+	echo io.Writer
+	csv  io.Writer
+	// lastSizes are the number of points of the last send per database, and lastEntries the number of entries reported
+	lastSizes   map[string]int
+	lastEntries int
+	// built and arenas are reused by build from one send to the next
+	built  []built
+	arenas [][]Point
+	// stateMu guards the state kept between sends by the goroutines building the points.
+	stateMu sync.Mutex
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	stopped  chan struct{}
 }
 
 // InfluxDB starts a InfluxDB reporter which will post the metrics from the given registry at each d interval.
 func InfluxDB(r metrics.Registry, d time.Duration, url, database, username, password string, tagHost bool) {
-	u, err := uurl.Parse(url)
+	rep, err := New(r, d, url, database, username, password, WithTagHost(tagHost))
 	if err != nil {
-		log.Printf("unable to parse InfluxDB url %s. err=%v", url, err)
+		log.Printf("unable to create InfluxDB reporter. err=%v", err)
 		return
 	}
 
-	rep := &reporter{
-		reg:      r,
-		interval: d,
-		tagHost:  tagHost,
-		url:      *u,
-		database: database,
-		username: username,
-		password: password,
+	rep.Run()
+}
+
+// New creates a InfluxDB reporter which will post the metrics from the given registry at each d interval.
+// The reporter does nothing until Run is called.
+// The url, username and password are ignored if the reporter is given a shared client with WithClient.
+func New(r metrics.Registry, d time.Duration, url, database, username, password string, opts ...Option) (*Reporter, error) {
+	var err error
+
+	rep := &Reporter{
+		sources:   []*Source{{Registry: r}},
+		interval:  d,
+		database:  database,
+		separator: ".",
+		sanitizer: DefaultSanitizer,
+		logger:    newLeveledLogger(),
+
+		eventMeasurement: "events",
+		clock:            realClock{},
+
+		percentiles:     defaultPercentiles,
+		percentileNames: percentileNames(defaultPercentiles),
+
+		durationUnit: time.Millisecond,
+		rateUnit:     time.Second,
+
+		typeSuffixes: defaultTypeSuffixes(),
+		lastCounts:   make(map[string]int64),
+		lastValues:   make(map[string]*lastValue),
+		lastCounters: make(map[string]int64),
+		lastWindows:  make(map[string]window),
+		dumps:        make(map[string]time.Time),
+		gaugeStats:   make(map[string]*gaugeStats),
+		buffer:       job{bs: make(batches)},
+		meta:         make(map[string]*meta),
+		lastSizes:    make(map[string]int),
+
+		intervals: make(chan time.Duration, 1),
+		flushes:   make(chan struct{}, 1),
+		queueSize: 1,
+		workers:   1,
+		stop:      make(chan struct{}),
+		stopped:   make(chan struct{}),
 	}
-	if err := rep.makeClient(); err != nil {
-		log.Printf("unable to make InfluxDB client. err=%v", err)
-		return
+	for _, opt := range opts {
+		opt(rep)
 	}
+	rep.logger.clock = rep.clock
 
-	rep.run()
+	if len(rep.percentileNames) != len(rep.percentiles) {
+		return nil, fmt.Errorf("got %d percentile names for %d percentiles", len(rep.percentileNames), len(rep.percentiles))
+	}
+	if rep.maxBatch < 0 {
+		return nil, fmt.Errorf("invalid batch size %d", rep.maxBatch)
+	}
+	if rep.workers < 1 {
+		return nil, fmt.Errorf("invalid number of workers %d", rep.workers)
+	}
+	if rep.queueSize < 1 {
+		return nil, fmt.Errorf("invalid send queue size %d", rep.queueSize)
+	}
+	if rep.jitter {
+		rep.jitterFraction = rand.New(rand.NewSource(rep.clock.Now().UnixNano())).Float64()
+	}
+	rep.typeEvery = make(map[string]int64, len(rep.typeIntervals))
+	for typ, d := range rep.typeIntervals {
+		if rep.interval <= 0 || d < rep.interval || d%rep.interval != 0 {
+			return nil, fmt.Errorf("the %s interval %s is not a multiple of the interval %s", typ, d, rep.interval)
+		}
+		rep.typeEvery[typ] = int64(d / rep.interval)
+	}
+	if rep.durationUnit <= 0 {
+		return nil, fmt.Errorf("invalid duration unit %s", rep.durationUnit)
+	}
+	if rep.rateUnit <= 0 {
+		return nil, fmt.Errorf("invalid rate unit %s", rep.rateUnit)
+	}
+	for _, spec := range rep.templateSpecs {
+		t, err := parseTemplate(spec)
+		if err != nil {
+			return nil, err
+		}
+		rep.templates = append(rep.templates, t)
+	}
+	if rep.include, err = compilePatterns(rep.includePatterns); err != nil {
+		return nil, err
+	}
+	if rep.exclude, err = compilePatterns(rep.excludePatterns); err != nil {
+		return nil, err
+	}
+	if rep.routes, err = compileRoutes(rep.routeSpecs); err != nil {
+		return nil, err
+	}
+	if rep.compiledTiers, err = compileTiers(rep.tiers, rep.interval); err != nil {
+		return nil, err
+	}
+	rep.tierCache = make(map[string]*tier)
+	for _, w := range rep.maintenance.windows {
+		if err := w.validate(); err != nil {
+			return nil, err
+		}
+	}
+	rep.intervalTags = make(map[time.Duration]map[string]string)
+	if rep.maxIdleSkips < 0 {
+		return nil, fmt.Errorf("invalid number of idle sends %d", rep.maxIdleSkips)
+	}
+	rep.idleValues = make(map[string]interface{})
+	rep.bucketLabels = bucketLabels(rep.bucketBounds)
+	for _, p := range rep.percentiles {
+		rep.quantiles = append(rep.quantiles, strconv.FormatFloat(p, 'f', -1, 64))
+	}
+
+	rep.extras = append(rep.extras, rep.metaPoints)
+
+	if rep.selfPrefix != "" {
+		rep.self = newSelfMetrics(r, rep.selfPrefix)
+	}
+
+	if rep.runtime {
+		rep.collectors = append(rep.collectors, RuntimeCollector(r))
+	}
+
+	if rep.schema != nil {
+		rep.schema.logger = rep.logger
+	}
+
+	if rep.client == nil {
+		rep.ownClient = true
+		opts := append([]ClientOption{WithClientLogger(rep.logger.Logger), WithClientLogLevel(rep.logger.level), WithClientLogRepeats(rep.logger.repeats.window), WithClientErrorHandler(rep.errorHandler), WithClientClock(rep.clock)}, rep.clientOptions...)
+		if rep.client, err = NewClient(url, username, password, opts...); err != nil {
+			return nil, err
+		}
+	}
+
+	if rep.shardClients != nil {
+		if rep.shards, err = newShards(rep.shardClients, rep.shardBy); err != nil {
+			return nil, err
+		}
+	}
+
+	if rep.startupCheck {
+		if err := rep.client.waitReachable(rep.startupRetries, rep.startupBackoff); err != nil {
+			return nil, err
+		}
+	}
+
+	if rep.databaseCheck {
+		if err := rep.checkDatabases(); err != nil {
+			return nil, err
+		}
+	}
+
+	if rep.healthName != "" {
+		r.GetOrRegister(rep.healthName, metrics.NewHealthcheck(rep.checkHealth))
+	}
+
+	if rep.expvarName != "" {
+		if err := rep.publish(rep.expvarName); err != nil {
+			return nil, err
+		}
+	}
+
+	return rep, nil
 }
 
-func (r *reporter) makeClient() (err error) {
-	r.client, err = client.NewClient(client.Config{
-		URL:      r.url,
-		Username: r.username,
-		Password: r.password,
-	})
+// checkDatabases checks that the databases written to exist, or creates them.
+func (r *Reporter) checkDatabases() error {
+	dbs := []string{r.database}
+	for _, rt := range r.routeSpecs {
+		dbs = append(dbs, rt.Database)
+	}
+	for _, src := range r.sources {
+		if src.Database != "" {
+			dbs = append(dbs, src.Database)
+		}
+	}
+
+	for _, db := range dbs {
+		ok, err := r.client.databaseExists(db)
+		if err != nil {
+			return fmt.Errorf("unable to check InfluxDB database %s. err=%v", db, err)
+		}
+		if ok {
+			continue
+		}
 
-	return
+		if !r.createDatabase {
+			return fmt.Errorf("InfluxDB database %s does not exist", db)
+		}
+		if err := r.client.createDatabase(db); err != nil {
+			return fmt.Errorf("unable to create InfluxDB database %s. err=%v", db, err)
+		}
+	}
+
+	return nil
 }
 
-func (r *reporter) run() {
-	intervalTicker := time.Tick(r.interval)
-	pingTicker := time.Tick(time.Second * 5)
+// Run posts the metrics at each interval, until Stop is called.
+// Its goroutines are labeled component=influx-reporter in the profiles.
+func (r *Reporter) Run() {
+	defer close(r.stopped)
+	if r.ownClient {
+		defer r.client.Close()
+	}
+
+	pprof.Do(context.Background(), pprof.Labels("component", "influx-reporter"), func(context.Context) {
+		if r.watchdog {
+			r.supervise()
+			return
+		}
+		r.run()
+	})
+}
+
+// run is the loop of Run. The goroutines it starts inherit its profiler labels.
+func (r *Reporter) run() {
+	r.client.startPinging()
+	for _, c := range r.shardClients {
+		c.startPinging()
+	}
+
+	sendTicker := r.intervalTicker()
+	defer func() {
+		sendTicker.Stop()
+	}()
+	subTicker, stopSub := tick(r.clock, r.subInterval)
+	defer stopSub()
+	flushTicker, stopFlush := tick(r.clock, r.flushInterval)
+	defer stopFlush()
+
+	p := newPipeline(r.queueSize)
+	r.pipe = p
+	go r.writer(p)
+
+	if r.started.IsZero() {
+		r.started = r.clock.Now()
+	}
+	r.progressed()
+
+	var signals chan os.Signal
+	if len(r.flushSignals) > 0 {
+		signals = make(chan os.Signal, 1)
+		signal.Notify(signals, r.flushSignals...)
+		defer signal.Stop(signals)
+	}
+
+	if r.immediate {
+		r.sendNow(p)
+	}
 
 	for {
+		r.progressed()
+
 		select {
-		case <-intervalTicker:
-			if err := r.send(); err != nil {
-				log.Printf("unable to send metrics to InfluxDB. err=%v", err)
+		case <-subTicker:
+			r.collect()
+		case <-r.flushes:
+			r.sendNow(p)
+		case <-signals:
+			r.sendNow(p)
+		case d := <-r.intervals:
+			sendTicker.Stop()
+			r.setInterval(d)
+			sendTicker = r.intervalTicker()
+		case <-sendTicker.C:
+			if r.flushInterval == 0 && r.backingOff() {
+				r.dropped(backedOffSends, 1)
+				break
+			}
+			r.tick(p)
+		case <-flushTicker:
+			if r.backingOff() {
+				r.dropped(backedOffSends, 1)
+				break
+			}
+			r.start(p, r.flush)
+		case err := <-p.done:
+			r.finish(p, err)
+		case <-r.stop:
+			var last []func() (job, error)
+			if p.queued != nil {
+				last = append(last, p.queued)
 			}
-		case <-pingTicker:
-			_, _, err := r.client.Ping()
-			if err != nil {
-				log.Printf("got error while sending a ping to InfluxDB, trying to recreate client. err=%v", err)
+			if r.flushInterval > 0 {
+				last = append(last, r.flush)
+			}
+			r.drain(p, last...)
+			return
+		}
+	}
+}
 
-				if err = r.makeClient(); err != nil {
-					log.Printf("unable to make InfluxDB client. err=%v", err)
-				}
+// Send sends the metrics once and waits for the write, including the points buffered with a flush interval.
+// It is meant for one-shot reporting and must not be called while Run is running. Its result is recorded
+// like the writes of Run, in the Status, the connection state and the consecutive failures.
+func (r *Reporter) Send() error {
+	j, err := r.send()
+	if err != nil {
+		return err
+	}
+	if r.flushInterval > 0 {
+		release(j)
+		j, _ = r.flush()
+	}
+	defer release(j)
+
+	failed, err := r.writeAll(j.bs)
+	for db := range failed {
+		r.retain(j.pending[db])
+	}
+	r.written(err)
+	if err != nil {
+		return err
+	}
+	clearAll(j.cleared)
+	return nil
+}
+
+// tick sends the metrics, or buffers them with a flush interval.
+func (r *Reporter) tick(p *pipeline) {
+	if r.flushInterval > 0 {
+		// sends only fill the buffer, the flushes write it
+		if _, err := r.send(); err != nil {
+			r.sendFailed(err)
+		}
+		return
+	}
+	r.start(p, r.send)
+}
+
+// sendNow sends the metrics, and flushes them with a flush interval.
+func (r *Reporter) sendNow(p *pipeline) {
+	r.tick(p)
+	if r.flushInterval > 0 {
+		r.start(p, r.flush)
+	}
+}
+
+// Flush makes a running reporter send the metrics now, and write the points buffered with a flush interval.
+func (r *Reporter) Flush() {
+	select {
+	case r.flushes <- struct{}{}:
+	default:
+		// a flush is already pending
+	}
+}
+
+// Stop stops Run once the points queued or buffered are written, and closes the client of the reporter
+// unless it was given one with WithClient. It must only be called after Run was started.
+func (r *Reporter) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+	<-r.stopped
+}
+
+// send returns the points to write and the metrics to clear once they are written.
+// With a flush interval, the points are buffered instead.
+func (r *Reporter) send() (job, error) {
+	r.runCollectors()
+
+	r.cfgMu.Lock()
+	defer r.cfgMu.Unlock()
+
+	j, err := r.points()
+	if err != nil {
+		return job{}, err
+	}
+
+	if r.flushInterval > 0 {
+		// the points are safe in the buffer, so the metrics can start over
+		for db, pts := range j.bs {
+			r.buffer.bs[db] = append(r.buffer.bs[db], pts...)
+		}
+		r.buffer.fields = append(r.buffer.fields, j.fields...)
+		for db, pts := range j.pending {
+			if r.buffer.pending == nil {
+				r.buffer.pending = make(batches)
 			}
+			r.buffer.pending[db] = append(r.buffer.pending[db], pts...)
 		}
+		clearAll(j.cleared)
+		release(job{bs: j.bs})
+		r.recordState()
+		return job{}, nil
 	}
+
+	return j, nil
 }
 
-func (r *reporter) send() error {
-	var pts []client.Point
+// flush returns the points buffered since the last flush.
+func (r *Reporter) flush() (job, error) {
+	j := r.buffer
+	r.buffer = job{bs: make(batches)}
+	r.recordState()
+
+	return j, nil
+}
+
+// batches maps databases to the points to write to them.
+type batches map[string][]Point
+
+// writeAll writes every batch and returns the databases whose write failed, and the first error.
+func (r *Reporter) writeAll(bs batches) (map[string]bool, error) {
+	var failed map[string]bool
+	var res error
+	n := 0
+	for db, pts := range bs {
+		if err := r.write(db, pts); err != nil {
+			if failed == nil {
+				failed = make(map[string]bool)
+			}
+			failed[db] = true
+			if res == nil {
+				res = err
+			}
+		}
+		n += len(pts)
+	}
+	if len(bs) > 0 {
+		r.status.wrote(n, res, r.clock.Now())
+	}
+	return failed, res
+}
+
+func (r *Reporter) write(database string, pts []client.Point) error {
+	r.writeSinks(database, pts)
+	if r.csv != nil {
+		return EncodeCSV(r.csv, pts)
+	}
+	if r.shards != nil {
+		return r.writeShards(database, pts)
+	}
+	return r.writeTo(r.client, database, pts)
+}
+
+// writeTo writes a batch of points to a database with a client.
+func (r *Reporter) writeTo(c *Client, database string, pts []client.Point) error {
+
+	r.enc.reset()
+	for i := range pts {
+		r.enc.point(&pts[i])
+	}
+	if len(r.enc.buf) == 0 {
+		return nil
+	}
+	if r.echo != nil {
+		if _, err := r.echo.Write(r.enc.buf); err != nil {
+			r.logger.logf(LogWarn, "unable to echo metrics. err=%v", err)
+		}
+	}
+
+	info := SendInfo{Database: database, Points: len(pts), Bytes: len(r.enc.buf), Start: r.clock.Now()}
+	var after func(SendInfo)
+	if r.sendHook != nil {
+		after = r.sendHook(info)
+	}
+	err := c.writeLines(database, r.enc.buf)
+	if err == nil {
+		r.self.wrote(info.Points, info.Bytes, since(r.clock, info.Start))
+		r.lastBatches.record(database, r.enc.buf, info.Start)
+	}
+	if after != nil {
+		info.Duration = since(r.clock, info.Start)
+		info.Err = err
+		after(info)
+	}
+	return err
+}
+
+// points returns the points of the registries, by database, and the metrics to clear once they are written.
+// When streaming, full batches are queued for the writer before it returns.
+func (r *Reporter) points() (job, error) {
+	r.sends++
+
+	bs := newBatches()
+	var cleared []clearer
+	var owned []map[string]interface{}
 
 	host := ""
 
 	if r.tagHost {
 		hostName, err := os.Hostname()
 		if err != nil {
-			return err
+			return job{}, err
 		}
 
-		host = hostName + "."
+		host = hostName + r.separator
 	}
 
-	r.reg.Each(func(name string, i interface{}) {
-		now := time.Now()
+	inv := make(inventory)
 
-		// Prefix the namespace with the host
-		name = host + name
+	// every point of a send has the same time, so that they group cleanly
+	now := r.timestamp()
+	es := r.snapshot(inv)
+	if r.maxIdleSkips > 0 && r.idle(es) {
+		// the heartbeat, the metadata and the pending points are still written
+		es = nil
+	}
 
-		switch m := i.(type) {
-		case metrics.Counter:
-			pts = append(pts, client.Point{
-				Measurement: fmt.Sprintf("%s.count", name),
-				Fields: map[string]interface{}{
-					"value": m.Count(),
-				},
-				Time: now,
-			})
-		case metrics.Gauge:
-			pts = append(pts, client.Point{
-				Measurement: fmt.Sprintf("%s.gauge", name),
-				Fields: map[string]interface{}{
-					"value": m.Value(),
-				},
-				Time: now,
-			})
-		case metrics.GaugeFloat64:
-			pts = append(pts, client.Point{
-				Measurement: fmt.Sprintf("%s.gauge", name),
-				Fields: map[string]interface{}{
-					"value": m.Value(),
-				},
-				Time: now,
-			})
-		case metrics.Histogram:
-			ps := m.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999, 0.9999})
-			pts = append(pts, client.Point{
-				Measurement: fmt.Sprintf("%s.histogram", name),
-				Fields: map[string]interface{}{
-					"count":    m.Count(),
-					"max":      m.Max(),
-					"mean":     m.Mean(),
-					"min":      m.Min(),
-					"stddev":   m.StdDev(),
-					"variance": m.Variance(),
-					"p50":      ps[0],
-					"p75":      ps[1],
-					"p95":      ps[2],
-					"p99":      ps[3],
-					"p999":     ps[4],
-					"p9999":    ps[5],
-				},
-				Time: now,
-			})
-		case metrics.Meter:
-			pts = append(pts, client.Point{
-				Measurement: fmt.Sprintf("%s.meter", name),
-				Fields: map[string]interface{}{
-					"count": m.Count(),
-					"m1":    m.Rate1(),
-					"m5":    m.Rate5(),
-					"m15":   m.Rate15(),
-					"mean":  m.RateMean(),
-				},
-				Time: now,
-			})
-		case metrics.Timer:
-			ps := m.Percentiles([]float64{0.5, 0.75, 0.95, 0.99, 0.999, 0.9999})
+	tags := r.tags
+	// during the warmup, the state of the metrics is updated, but the startup values are dropped
+	paused := since(r.clock, r.started) < r.warmup
+	if r.inMaintenance(now) {
+		if r.maintenanceMode == TagMaintenance {
+			tags = mergeTags(r.tags, maintenanceTags)
+		} else {
+			paused = true
+		}
+	}
+
+	// when streaming, the points are built and written maxBatch entries at a time
+	chunk := len(es)
+	if r.streaming() {
+		chunk = r.maxBatch
+	}
+
+	var streamed int64
+	for k := 0; k < len(es); k += chunk {
+		end := k + chunk
+		if end > len(es) {
+			end = len(es)
+		}
+
+		for j, b := range r.build(host, es[k:end], now) {
+			e := es[k+j]
+			mpts := b.pts
+			if len(mpts) == 0 {
+				continue
+			}
+			addTags(mpts, e.src.Tags)
+			addTags(mpts, tags)
+			r.addInterval(b, e)
+			if b.owned {
+				for _, pt := range mpts {
+					owned = append(owned, pt.Fields)
+				}
+			}
+			if c, ok := e.metric.(clearer); ok && r.clearOnFlush {
+				cleared = append(cleared, c)
+			}
+			if paused {
+				// the metrics are up to date, the points are only dropped
+				continue
+			}
+
+			db := e.src.Database
+			if db == "" {
+				db = r.routeDatabase(e.name)
+			}
+			r.addPoints(bs, db, mpts)
+
+			if !r.streaming() {
+				continue
+			}
+			for db, pts := range bs {
+				if len(pts) < r.maxBatch {
+					continue
+				}
+				pts = r.process(pts)
+				delete(bs, db)
+				streamed += int64(len(pts))
+				if len(pts) > 0 {
+					sbs := newBatches()
+					sbs[db] = pts
+					r.push(r.pipe, job{bs: sbs})
+				}
+			}
+		}
+	}
+
+	for _, extra := range r.extras {
+		pts := extra(now)
+		addTags(pts, tags)
+		r.addPoints(bs, r.database, pts)
+	}
+
+	// the pending points are kept as they were given, by database, to be sent again if their write fails
+	var pending batches
+	if given := r.pendingPoints(now); len(given) > 0 {
+		pending = make(batches)
+		pts := append([]Point(nil), given...)
+		addTags(pts, tags)
+		for i := range pts {
+			db := r.databaseOf(r.database, &pts[i])
+			pending[db] = append(pending[db], given[i])
+		}
+		r.addPoints(bs, r.database, pts)
+	}
+
+	for db, pts := range bs {
+		pts = r.process(pts)
+		if len(pts) == 0 {
+			delete(bs, db)
+			continue
+		}
+		bs[db] = pts
+	}
+
+	if r.inventory {
+		// the inventory counts the points written, so it is processed last, on its own
+		pts := []Point{inv.point(r.prefix+"registry_inventory", bs, streamed, now)}
+		addTags(pts, tags)
+		r.addPoints(bs, r.database, r.process(pts))
+	}
+
+	for db, pts := range bs {
+		r.lastSizes[db] = len(pts)
+	}
+
+	return job{bs: bs, cleared: cleared, fields: owned, pending: pending}, nil
+}
+
+// newPoints returns an empty point slice for a database, large enough for as many points as the last send.
+func (r *Reporter) newPoints(db string) []Point {
+	pts := newPoints()
+	if n := r.lastSizes[db]; cap(pts) < n {
+		pts = make([]Point, 0, n)
+	}
+	return pts
+}
+
+// metricPoints appends the points of a registry entry to pts.
+func (r *Reporter) metricPoints(pts []Point, host string, e entry, now time.Time) []Point {
+
+	name := e.name
+	typ, fields, ps := r.fields(name, e.snap)
+	if fields == nil {
+		return pts
+	}
+
+	if typ == TypeGauge && r.subInterval > 0 {
+		r.downsampledFields(name, fields)
+	}
+	if r.windowed && typ == TypeHistogram {
+		r.windowFields(e, 1, fields)
+	} else if r.windowed && typ == TypeTimer {
+		r.windowFields(e, float64(r.durationUnit.Nanoseconds()), fields)
+	}
+
+	if typ == TypeCounter && r.counterDeltas {
+		count := fields["value"].(int64)
+		fields["value"] = r.delta(name, count)
+		if r.counterCumulative {
+			fields["count"] = count
+		}
+	} else if typ == TypeCounter && r.deltaField {
+		fields["delta"] = r.delta(name, fields["value"].(int64))
+	}
+
+	measurement, tags, ok := r.series(host, name, typ)
+	if !ok {
+		putFields(fields)
+		return pts
+	}
+
+	if r.unitTags {
+		if u := r.unit(name, typ); u != "" {
+			tags = withTag(tags, "unit", u)
+		}
+	}
+
+	if r.quantilePoints {
+		for j, p := range ps {
 			pts = append(pts, client.Point{
-				Measurement: fmt.Sprintf("%s.timer", name),
-				Fields: map[string]interface{}{
-					"count":    m.Count(),
-					"max":      m.Max() / time.Millisecond.Nanoseconds(),               // ms time
-					"mean":     m.Mean() / float64(time.Millisecond.Nanoseconds()),     // ms time
-					"min":      m.Min() / time.Millisecond.Nanoseconds(),               // ms time
-					"stddev":   m.StdDev() / float64(time.Millisecond.Nanoseconds()),   // ms time
-					"variance": m.Variance() / float64(time.Millisecond.Nanoseconds()), // ms time
-					"p50":      ps[0] / float64(time.Millisecond.Nanoseconds()),        // ms time
-					"p75":      ps[1] / float64(time.Millisecond.Nanoseconds()),        // ms time
-					"p95":      ps[2] / float64(time.Millisecond.Nanoseconds()),        // ms time
-					"p99":      ps[3] / float64(time.Millisecond.Nanoseconds()),        // ms time
-					"p999":     ps[4] / float64(time.Millisecond.Nanoseconds()),        // ms time
-					"p9999":    ps[5] / float64(time.Millisecond.Nanoseconds()),        // ms time
-					"m1":       m.Rate1(),
-					"m5":       m.Rate5(),
-					"m15":      m.Rate15(),
-					"meanrate": m.RateMean(),
-				},
+				Measurement: measurement,
+				Tags:        withTag(tags, "quantile", r.quantiles[j]),
+				Fields: r.renameFields(typ, map[string]interface{}{
+					"value": p,
+				}),
 				Time: now,
 			})
 		}
+	} else {
+		for j, p := range ps {
+			fields[r.percentileNames[j]] = p
+		}
+	}
+
+	if counts := r.buckets(typ, e.snap); counts != nil {
+		for j, c := range counts {
+			if r.bucketPoints {
+				pts = append(pts, client.Point{
+					Measurement: measurement,
+					Tags:        withTag(tags, "le", r.bucketLabels[j]),
+					Fields: r.renameFields(typ, map[string]interface{}{
+						"count": c,
+					}),
+					Time: now,
+				})
+			} else {
+				fields[bucketField(r.bucketLabels[j])] = c
+			}
+		}
+	}
+
+	if r.dumping(name, now) {
+		pts = append(pts, r.samplePoints(measurement, tags, typ, e.metric, now)...)
+	}
+
+	if r.floatFields {
+		toFloat(typ, fields, r.integerFields)
+	}
+
+	return append(pts, client.Point{
+		Measurement: measurement,
+		Tags:        tags,
+		Fields:      r.renameFields(typ, fields),
+		Time:        now,
 	})
+}
+
+// clearer is implemented by counters and histograms, the metrics cleared by WithClearOnFlush.
+type clearer interface {
+	Clear()
+}
 
-	bps := client.BatchPoints{
-		Points:   pts,
-		Database: r.database,
+func clearAll(cs []clearer) {
+	for _, c := range cs {
+		c.Clear()
 	}
+}
 
-	_, err := r.client.Write(bps)
-	return err
+// withTag returns a copy of tags with one more tag.
+func withTag(tags map[string]string, k, v string) map[string]string {
+	res := make(map[string]string, len(tags)+1)
+	for tk, tv := range tags {
+		res[tk] = tv
+	}
+	res[k] = v
+	return res
 }
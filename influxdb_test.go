@@ -0,0 +1,201 @@
+package influxdb
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// unreachable is the url of the reporters of the tests which only build the points.
+const unreachable = "http://127.0.0.1:8086"
+
+// newTestReporter returns a reporter writing to url every second, which doesn't ping.
+func newTestReporter(t *testing.T, url string, reg metrics.Registry, opts ...Option) *Reporter {
+	t.Helper()
+
+	opts = append([]Option{WithClientOptions(WithPingInterval(0))}, opts...)
+	r, err := New(reg, time.Second, url, "db", "", "", opts...)
+	if err != nil {
+		t.Fatalf("unable to create reporter. err=%v", err)
+	}
+	return r
+}
+
+// newTestClient returns a client of url which doesn't ping, closed at the end of the test.
+func newTestClient(t *testing.T, url string, opts ...ClientOption) *Client {
+	t.Helper()
+
+	c, err := NewClient(url, "", "", append([]ClientOption{WithPingInterval(0)}, opts...)...)
+	if err != nil {
+		t.Fatalf("unable to create client. err=%v", err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+// snapshotPoints returns the points of a send of the reporter, by measurement.
+func snapshotPoints(t *testing.T, r *Reporter) map[string]Point {
+	t.Helper()
+
+	pts, err := r.Snapshot()
+	if err != nil {
+		t.Fatalf("unable to snapshot. err=%v", err)
+	}
+	res := make(map[string]Point, len(pts))
+	for _, pt := range pts {
+		res[pt.Measurement] = pt
+	}
+	return res
+}
+
+// fakeInflux is an InfluxDB server recording the writes, which fails the writes to the databases of fail.
+type fakeInflux struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	fail   map[string]bool
+	writes []fakeWrite
+	closed int
+}
+
+type fakeWrite struct {
+	database string
+	encoding string
+	body     []byte
+}
+
+func newFakeInflux(t *testing.T) *fakeInflux {
+	f := &fakeInflux{fail: make(map[string]bool)}
+	f.Server = httptest.NewUnstartedServer(f)
+	f.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			f.mu.Lock()
+			f.closed++
+			f.mu.Unlock()
+		}
+	}
+	f.Start()
+	t.Cleanup(f.Close)
+	return f
+}
+
+// closedConns returns the number of connections closed so far.
+func (f *fakeInflux) closedConns() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+func (f *fakeInflux) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/write" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	body, _ := io.ReadAll(req.Body)
+	db := req.URL.Query().Get("db")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail[db] {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error":"failed"}`))
+		return
+	}
+	f.writes = append(f.writes, fakeWrite{database: db, encoding: req.Header.Get("Content-Encoding"), body: body})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// failing makes the writes to a database fail, or succeed again.
+func (f *fakeInflux) failing(db string, fail bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fail[db] = fail
+}
+
+// lines returns the lines written to a database, without their timestamps, and empties the writes.
+func (f *fakeInflux) lines(db string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var res []string
+	var kept []fakeWrite
+	for _, w := range f.writes {
+		if w.database != db {
+			kept = append(kept, w)
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(string(w.body), "\n"), "\n") {
+			if i := strings.LastIndexByte(line, ' '); i > 0 && strings.Count(line, " ") > 1 {
+				line = line[:i]
+			}
+			res = append(res, line)
+		}
+	}
+	f.writes = kept
+	return res
+}
+
+func TestWarmup(t *testing.T) {
+	clock := newFakeClock()
+	reg := metrics.NewRegistry()
+	c := metrics.GetOrRegisterCounter("requests", reg)
+	r := newTestReporter(t, unreachable, reg, WithClock(clock), WithWarmup(time.Minute), WithCounterDeltas(false), WithBuildInfo(BuildInfo{Version: "1.0"}, true))
+	r.started = clock.Now()
+
+	c.Inc(100)
+	r.Event("deploy", "1.0", nil)
+	pts := snapshotPoints(t, r)
+	if _, ok := pts["requests.count"]; ok {
+		t.Error("metric points written during the warmup")
+	}
+	for _, m := range []string{"events", "build_info"} {
+		if _, ok := pts[m]; !ok {
+			t.Errorf("no %s point during the warmup", m)
+		}
+	}
+
+	// the deltas start from the state of the warmup
+	clock.Advance(time.Minute)
+	c.Inc(2)
+	pts = snapshotPoints(t, r)
+	if got := pts["requests.count"].Fields["value"]; got != int64(2) {
+		t.Errorf("value is %v after the warmup, want 2", got)
+	}
+	if _, ok := pts["build_info"]; ok {
+		t.Error("build_info point written twice")
+	}
+}
+
+func TestSend(t *testing.T) {
+	influx := newFakeInflux(t)
+	reg := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", reg).Inc(1)
+	var handled []error
+	r := newTestReporter(t, influx.URL, reg, WithErrorHandler(func(err error) { handled = append(handled, err) }), WithLogger(NopLogger))
+
+	// the one-shot sends are recorded like the ones of Run
+	influx.failing("db", true)
+	for i := 0; i < 2; i++ {
+		if err := r.Send(); err == nil {
+			t.Fatal("the send succeeded, want it to fail")
+		}
+	}
+	if st := r.Status(); st.Failures != 2 || st.State == Connected || len(handled) != 2 {
+		t.Errorf("got %d failures, the state %s and %d handled errors, want 2 failures", st.Failures, st.State, len(handled))
+	}
+
+	influx.failing("db", false)
+	if err := r.Send(); err != nil {
+		t.Fatalf("unable to send. err=%v", err)
+	}
+	if st := r.Status(); st.Failures != 0 || st.State != Connected || st.LastPoints != 1 {
+		t.Errorf("got %d failures, the state %s and %d points, want the success recorded", st.Failures, st.State, st.LastPoints)
+	}
+}
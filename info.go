@@ -0,0 +1,105 @@
+package influxdb
+
+import (
+	"os"
+	"runtime"
+	"time"
+)
+
+// BuildInfo describes the build of the application, written by WithBuildInfo.
+type BuildInfo struct {
+	Version  string
+	Revision string
+	Date     string
+}
+
+// buildInfoPoints returns a build_info point, tagged with the build info and the Go version.
+// Written once, it is given to WritePoints instead, so that it is sent again until a write succeeds.
+func (r *Reporter) buildInfoPoints(info BuildInfo, once bool) func(now time.Time) []Point {
+	queued := false
+
+	return func(now time.Time) []Point {
+		if once && queued {
+			return nil
+		}
+
+		pts := []Point{{
+			Measurement: r.prefix + "build_info",
+			Tags: map[string]string{
+				"version":    info.Version,
+				"revision":   info.Revision,
+				"build_date": info.Date,
+				"go_version": runtime.Version(),
+			},
+			Fields: map[string]interface{}{
+				"value": int64(1),
+			},
+			Time: now,
+		}}
+		if once {
+			queued = true
+			r.WritePoints(pts...)
+			return nil
+		}
+		return pts
+	}
+}
+
+// hostInfoPoints returns a host_info point every n sends, starting with the first one.
+func (r *Reporter) hostInfoPoints(n int) func(now time.Time) []Point {
+	sends := 0
+
+	return func(now time.Time) []Point {
+		sends++
+		if due := sends == 1 || n > 0 && (sends-1)%n == 0; !due {
+			return nil
+		}
+
+		tags := map[string]string{
+			"os":   runtime.GOOS,
+			"arch": runtime.GOARCH,
+		}
+		if hostName, err := os.Hostname(); err == nil {
+			tags["host"] = hostName
+		}
+
+		return []Point{{
+			Measurement: r.prefix + "host_info",
+			Tags:        tags,
+			Fields: map[string]interface{}{
+				"cpus": int64(runtime.NumCPU()),
+				"pid":  int64(os.Getpid()),
+			},
+			Time: now,
+		}}
+	}
+}
+
+// heartbeatPoints returns a heartbeat point at each send, tagged with the host name and holding
+// the seconds elapsed since Run started, or since the first heartbeat of a reporter which doesn't run.
+func (r *Reporter) heartbeatPoints() func(now time.Time) []Point {
+	var first time.Time
+	tags := map[string]string{}
+	if hostName, err := os.Hostname(); err == nil {
+		tags["host"] = hostName
+	}
+
+	return func(now time.Time) []Point {
+		if first.IsZero() {
+			first = now
+		}
+		start := r.started
+		if start.IsZero() {
+			start = first
+		}
+
+		return []Point{{
+			Measurement: r.prefix + "heartbeat",
+			Tags:        tags,
+			Fields: map[string]interface{}{
+				"uptime": now.Sub(start).Seconds(),
+			},
+			Time: now,
+		}}
+	}
+}
@@ -0,0 +1,73 @@
+package influxdb
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestHeartbeat(t *testing.T) {
+	influx := newFakeInflux(t)
+	clock := newFakeClock()
+	r := newTestReporter(t, influx.URL, metrics.NewRegistry(), WithHeartbeat(), WithClock(clock))
+
+	// the uptime counts from Run, not from the options
+	clock.Advance(time.Hour)
+	go r.Run()
+	clock.waitTimers(1)
+	clock.Advance(time.Second)
+	r.Stop()
+
+	var uptimes []string
+	for _, line := range influx.lines("db") {
+		if !strings.HasPrefix(line, "heartbeat,") {
+			t.Errorf("unexpected line %q", line)
+			continue
+		}
+		uptimes = append(uptimes, line[strings.LastIndexByte(line, ' ')+1:])
+	}
+	if want := "uptime=1"; strings.Join(uptimes, ",") != want {
+		t.Errorf("got %q, want %s", uptimes, want)
+	}
+}
+
+func TestHeartbeatWithoutRun(t *testing.T) {
+	clock := newFakeClock()
+	r := newTestReporter(t, unreachable, metrics.NewRegistry(), WithClock(clock), WithHeartbeat())
+
+	clock.Advance(time.Hour)
+	for _, want := range []float64{0, 5} {
+		pt, ok := snapshotPoints(t, r)["heartbeat"]
+		if !ok {
+			t.Fatal("no heartbeat point")
+		}
+		if got := pt.Fields["uptime"]; got != want {
+			t.Errorf("uptime is %v, want %v", got, want)
+		}
+		clock.Advance(5 * time.Second)
+	}
+}
+
+func TestBuildInfoOnce(t *testing.T) {
+	influx := newFakeInflux(t)
+	r := newTestReporter(t, influx.URL, metrics.NewRegistry(), WithBuildInfo(BuildInfo{Version: "1.0"}, true))
+
+	// the point is written once, by the first send which succeeds
+	influx.failing("db", true)
+	if err := r.Send(); err == nil {
+		t.Fatal("the send succeeded, want it to fail")
+	}
+	influx.failing("db", false)
+	for i := 0; i < 2; i++ {
+		if err := r.Send(); err != nil {
+			t.Fatalf("unable to send. err=%v", err)
+		}
+	}
+
+	lines := influx.lines("db")
+	if len(lines) != 1 || !strings.HasPrefix(lines[0], "build_info,") {
+		t.Errorf("got the lines %q, want one build_info point", lines)
+	}
+}
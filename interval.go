@@ -0,0 +1,52 @@
+package influxdb
+
+import (
+	"time"
+)
+
+// entryInterval returns the interval between the points of an entry: the interval of the reporter,
+// times the interval of its type and of its tier, if any.
+func (r *Reporter) entryInterval(e entry) time.Duration {
+	d := r.interval
+	if n, ok := r.typeEvery[metricType(e.metric)]; ok {
+		d *= time.Duration(n)
+	}
+	if len(r.compiledTiers) > 0 {
+		if t := r.tierOf(e.name); t != nil {
+			d *= time.Duration(t.every)
+		}
+	}
+	return d
+}
+
+// addInterval adds the interval of an entry to its points, as a tag and a field in milliseconds, if enabled.
+// The field maps the reporter doesn't own are copied, so that custom metrics keep theirs.
+func (r *Reporter) addInterval(b built, e entry) {
+	if r.intervalTag == "" && r.intervalField == "" {
+		return
+	}
+
+	d := r.entryInterval(e)
+	if r.intervalTag != "" {
+		tags, ok := r.intervalTags[d]
+		if !ok {
+			tags = map[string]string{r.intervalTag: d.String()}
+			r.intervalTags[d] = tags
+		}
+		addTags(b.pts, tags)
+	}
+	if r.intervalField == "" {
+		return
+	}
+	for i := range b.pts {
+		pt := &b.pts[i]
+		if !b.owned {
+			fields := make(map[string]interface{}, len(pt.Fields)+1)
+			for k, v := range pt.Fields {
+				fields[k] = v
+			}
+			pt.Fields = fields
+		}
+		pt.Fields[r.intervalField] = int64(d / time.Millisecond)
+	}
+}
@@ -0,0 +1,41 @@
+package influxdb
+
+import (
+	"time"
+)
+
+// inventory counts the registry entries per metric type during a send.
+type inventory map[string]int64
+
+func (inv inventory) add(i interface{}) {
+	typ := metricType(i)
+	if typ == "" {
+		typ = "other"
+	}
+	inv[typ]++
+}
+
+// point returns a registry_inventory point holding the number of entries per metric type, their total,
+// and the number of points produced by the send: the ones in bs and the ones already streamed.
+func (inv inventory) point(measurement string, bs batches, streamed int64, now time.Time) Point {
+	fields := make(map[string]interface{}, len(inv)+2)
+
+	var total int64
+	for typ, n := range inv {
+		fields[typ] = n
+		total += n
+	}
+	fields["total"] = total
+
+	points := streamed
+	for _, pts := range bs {
+		points += int64(len(pts))
+	}
+	fields["points"] = points
+
+	return Point{
+		Measurement: measurement,
+		Fields:      fields,
+		Time:        now,
+	}
+}
@@ -0,0 +1,35 @@
+package influxdb
+
+import (
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestInventory(t *testing.T) {
+	reg := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", reg).Inc(1)
+	metrics.GetOrRegisterGauge("queue", reg).Update(1)
+
+	// the inventory goes through the middlewares like the other points
+	stage := func(pts []Point) []Point {
+		for i := range pts {
+			pts[i].Tags = mergeTags(pts[i].Tags, map[string]string{"stage": "canary"})
+		}
+		return pts
+	}
+	r := newTestReporter(t, unreachable, reg, WithInventory(true), WithMiddlewares(stage))
+
+	pt, ok := snapshotPoints(t, r)["registry_inventory"]
+	if !ok {
+		t.Fatal("no registry_inventory point")
+	}
+	if got := pt.Tags["stage"]; got != "canary" {
+		t.Errorf("stage is %q, want the tag of the middleware", got)
+	}
+	for k, want := range map[string]int64{"counter": 1, "gauge": 1, "total": 2, "points": 2} {
+		if got := pt.Fields[k]; got != want {
+			t.Errorf("%s is %v, want %d", k, got, want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package influxdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/influxdata/influxdb/models"
+)
+
+// jsonPoint is the JSON encoding of a point.
+type jsonPoint struct {
+	Name      string                 `json:"name"`
+	Tags      map[string]string      `json:"tags,omitempty"`
+	Fields    map[string]interface{} `json:"fields"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// EncodeJSON writes points as JSON, one object per line with the name, tags, fields and timestamp
+// of the point, for consumers which don't speak the line protocol, like log pipelines, or golden tests.
+// The keys are sorted, so the same points always give the same output. Points without fields are skipped.
+func EncodeJSON(w io.Writer, pts []Point) error {
+	enc := json.NewEncoder(w)
+	for i := range pts {
+		jps, err := toJSON(&pts[i])
+		if err != nil {
+			return err
+		}
+		for _, jp := range jps {
+			if err := enc.Encode(jp); err != nil {
+				return fmt.Errorf("unable to encode point %s. err=%v", jp.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// toJSON returns the JSON encoding of a point, or of the points of its raw line protocol.
+func toJSON(pt *Point) ([]jsonPoint, error) {
+	if pt.Raw == "" {
+		if len(pt.Fields) == 0 {
+			return nil, nil
+		}
+		return []jsonPoint{{Name: pt.Measurement, Tags: pt.Tags, Fields: pt.Fields, Timestamp: pt.Time}}, nil
+	}
+	return parseJSON([]byte(pt.Raw))
+}
+
+// parseJSON returns the JSON encoding of points encoded in the line protocol.
+func parseJSON(lines []byte) ([]jsonPoint, error) {
+	pts, err := models.ParsePoints(lines)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse points. err=%v", err)
+	}
+
+	res := make([]jsonPoint, len(pts))
+	for i, pt := range pts {
+		fields, err := pt.Fields()
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse the fields of %s. err=%v", pt.Name(), err)
+		}
+		res[i] = jsonPoint{Name: string(pt.Name()), Tags: pt.Tags().Map(), Fields: fields, Timestamp: pt.Time()}
+	}
+	return res, nil
+}
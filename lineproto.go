@@ -0,0 +1,144 @@
+package influxdb
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/influxdb/client"
+)
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	tagEscaper         = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+	fieldKeyEscaper    = strings.NewReplacer(",", `\,`, `"`, `\"`, " ", `\ `, "=", `\=`)
+	stringEscaper      = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+)
+
+// AppendLines appends points encoded in the line protocol, with nanosecond timestamps, to b,
+// for sinks and custom delivery paths. Points without fields are skipped.
+func AppendLines(b []byte, pts []Point) []byte {
+	e := encoder{buf: b}
+	for i := range pts {
+		e.point(&pts[i])
+	}
+	return e.buf
+}
+
+// encoder encodes points in the line protocol. Its buffers are reused from one batch to the next,
+// so it must only be used by one goroutine.
+type encoder struct {
+	buf  []byte
+	keys []string
+}
+
+// reset empties the buffer, keeping its capacity.
+func (e *encoder) reset() {
+	e.buf = e.buf[:0]
+}
+
+// point appends a point and a line break. Points without fields are skipped.
+func (e *encoder) point(pt *Point) {
+	if pt.Raw != "" {
+		e.buf = append(e.buf, pt.Raw...)
+		e.buf = append(e.buf, '\n')
+		return
+	}
+	if len(pt.Fields) == 0 {
+		return
+	}
+
+	e.buf = append(e.buf, measurementEscaper.Replace(pt.Measurement)...)
+
+	for _, k := range e.sorted(tagKeys(e.keys[:0], pt.Tags)) {
+		if k == "" || pt.Tags[k] == "" {
+			// InfluxDB rejects the whole batch for a tag without a value
+			continue
+		}
+		e.buf = append(e.buf, ',')
+		e.buf = append(e.buf, tagEscaper.Replace(k)...)
+		e.buf = append(e.buf, '=')
+		e.buf = append(e.buf, tagEscaper.Replace(pt.Tags[k])...)
+	}
+
+	e.buf = append(e.buf, ' ')
+	for j, k := range e.sorted(fieldKeys(e.keys[:0], pt.Fields)) {
+		if j > 0 {
+			e.buf = append(e.buf, ',')
+		}
+		e.buf = append(e.buf, fieldKeyEscaper.Replace(k)...)
+		e.buf = append(e.buf, '=')
+		e.buf = appendValue(e.buf, pt.Fields[k])
+	}
+
+	if !pt.Time.IsZero() {
+		e.buf = append(e.buf, ' ')
+		e.buf = strconv.AppendInt(e.buf, client.SetPrecision(pt.Time, pt.Precision).UnixNano(), 10)
+	}
+	e.buf = append(e.buf, '\n')
+}
+
+// sorted sorts keys and keeps them for the next point.
+func (e *encoder) sorted(keys []string) []string {
+	if len(keys) > 1 {
+		sort.Strings(keys)
+	}
+	e.keys = keys
+	return keys
+}
+
+func tagKeys(keys []string, tags map[string]string) []string {
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func fieldKeys(keys []string, fields map[string]interface{}) []string {
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// appendValue appends a field value the way the InfluxDB client does.
+func appendValue(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case float64:
+		return strconv.AppendFloat(b, v, 'f', -1, 64)
+	case float32:
+		return strconv.AppendFloat(b, float64(v), 'f', -1, 32)
+	case int64:
+		return append(strconv.AppendInt(b, v, 10), 'i')
+	case int:
+		return append(strconv.AppendInt(b, int64(v), 10), 'i')
+	case int32:
+		return append(strconv.AppendInt(b, int64(v), 10), 'i')
+	case int16:
+		return append(strconv.AppendInt(b, int64(v), 10), 'i')
+	case int8:
+		return append(strconv.AppendInt(b, int64(v), 10), 'i')
+	case uint64:
+		return append(strconv.AppendUint(b, v, 10), 'u')
+	case uint32:
+		return append(strconv.AppendUint(b, uint64(v), 10), 'i')
+	case uint16:
+		return append(strconv.AppendUint(b, uint64(v), 10), 'i')
+	case uint8:
+		return append(strconv.AppendUint(b, uint64(v), 10), 'i')
+	case uint:
+		return append(strconv.AppendInt(b, int64(v), 10), 'i')
+	case bool:
+		return strconv.AppendBool(b, v)
+	case string:
+		return appendString(b, v)
+	}
+	return appendString(b, fmt.Sprintf("%v", v))
+}
+
+func appendString(b []byte, s string) []byte {
+	b = append(b, '"')
+	b = append(b, stringEscaper.Replace(s)...)
+	return append(b, '"')
+}
@@ -0,0 +1,117 @@
+package influxdb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAppendLines(t *testing.T) {
+	at := time.Date(2020, 1, 1, 0, 0, 1, 123456789, time.UTC)
+
+	tests := []struct {
+		name string
+		pt   Point
+		want string
+	}{
+		{
+			name: "types",
+			pt: Point{
+				Measurement: "m",
+				Fields: map[string]interface{}{
+					"f": 1.5,
+					"i": int64(-2),
+					"u": uint64(3),
+					"b": true,
+					"s": "ok",
+				},
+			},
+			want: "m b=true,f=1.5,i=-2i,s=\"ok\",u=3u\n",
+		},
+		{
+			name: "sorted tags",
+			pt: Point{
+				Measurement: "m",
+				Tags:        map[string]string{"z": "1", "a": "2"},
+				Fields:      map[string]interface{}{"value": int64(1)},
+			},
+			want: "m,a=2,z=1 value=1i\n",
+		},
+		{
+			name: "escaped measurement",
+			pt: Point{
+				Measurement: "a b,c=d",
+				Fields:      map[string]interface{}{"value": int64(1)},
+			},
+			want: "a\\ b\\,c=d value=1i\n",
+		},
+		{
+			name: "escaped tags",
+			pt: Point{
+				Measurement: "m",
+				Tags:        map[string]string{"k y,=": "v a,l=ue"},
+				Fields:      map[string]interface{}{"value": int64(1)},
+			},
+			want: "m,k\\ y\\,\\==v\\ a\\,l\\=ue value=1i\n",
+		},
+		{
+			name: "escaped field keys and strings",
+			pt: Point{
+				Measurement: "m",
+				Fields:      map[string]interface{}{`a "b",c=d`: `say "hi" \o/`},
+			},
+			want: "m a\\ \\\"b\\\"\\,c\\=d=\"say \\\"hi\\\" \\\\o/\"\n",
+		},
+		{
+			name: "empty tag value",
+			pt: Point{
+				Measurement: "m",
+				Tags:        map[string]string{"host": "", "service": "api"},
+				Fields:      map[string]interface{}{"value": int64(1)},
+			},
+			want: "m,service=api value=1i\n",
+		},
+		{
+			name: "empty tag key",
+			pt: Point{
+				Measurement: "m",
+				Tags:        map[string]string{"": "x"},
+				Fields:      map[string]interface{}{"value": int64(1)},
+			},
+			want: "m value=1i\n",
+		},
+		{
+			name: "no fields",
+			pt:   Point{Measurement: "m", Tags: map[string]string{"a": "b"}},
+			want: "",
+		},
+		{
+			name: "raw",
+			pt:   Point{Raw: "m value=1i 1"},
+			want: "m value=1i 1\n",
+		},
+		{
+			name: "nanoseconds",
+			pt:   Point{Measurement: "m", Fields: map[string]interface{}{"value": int64(1)}, Time: at},
+			want: "m value=1i 1577836801123456789\n",
+		},
+		{
+			name: "milliseconds",
+			pt:   Point{Measurement: "m", Fields: map[string]interface{}{"value": int64(1)}, Time: at, Precision: "ms"},
+			want: "m value=1i 1577836801123000000\n",
+		},
+		{
+			name: "seconds",
+			pt:   Point{Measurement: "m", Fields: map[string]interface{}{"value": int64(1)}, Time: at, Precision: "s"},
+			want: "m value=1i 1577836801000000000\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(AppendLines(nil, []Point{tt.pt}))
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package influxdb
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// maxNameLength is the length above which a metric name is likely a mistake.
+const maxNameLength = 200
+
+var (
+	// idSegment matches the name segments holding an identifier, which create a measurement per value:
+	// numbers, UUIDs, hexadecimal hashes and IP addresses.
+	idSegment = regexp.MustCompile(`^(\d{3,}|[0-9a-fA-F]{8}(-?[0-9a-fA-F]{4}){3}-?[0-9a-fA-F]{12}|[0-9a-fA-F]{12,}|\d{1,3}(_\d{1,3}){3})$`)
+	// nameSegments splits a name on the usual separators.
+	nameSegments = regexp.MustCompile(`[./:]`)
+)
+
+// NameErrors are the problems found in metric names by ValidateName and ValidateRegistry.
+type NameErrors []error
+
+func (e NameErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "invalid metric names: " + strings.Join(msgs, "; ")
+}
+
+// ValidateName checks that a metric name makes a good measurement with the default settings.
+// It flags the names which would be rewritten by the DefaultSanitizer, the names with a segment
+// looking like an identifier, which creates a measurement per value, and the names ending
+// with a type suffix, which would be doubled. It returns all the problems as NameErrors, or nil.
+func ValidateName(name string) error {
+	var errs NameErrors
+
+	switch {
+	case name == "":
+		errs = append(errs, fmt.Errorf("empty name"))
+	case !utf8.ValidString(name):
+		errs = append(errs, fmt.Errorf("name %q is not valid UTF-8", name))
+	case len(name) > maxNameLength:
+		errs = append(errs, fmt.Errorf("name %q is longer than %d bytes", name, maxNameLength))
+	}
+	if name == "" {
+		return errs
+	}
+
+	if DefaultSanitizer(name) != name {
+		errs = append(errs, fmt.Errorf("name %q has characters of the line protocol and would be written as %q", name, DefaultSanitizer(name)))
+	}
+	if strings.HasPrefix(name, "_") {
+		errs = append(errs, fmt.Errorf("name %q starts with an underscore, reserved by InfluxDB", name))
+	}
+	for _, s := range nameSegments.Split(name, -1) {
+		if idSegment.MatchString(s) {
+			errs = append(errs, fmt.Errorf("name %q has the segment %q looking like an identifier, use a tag instead", name, s))
+		}
+	}
+	for typ, suffix := range defaultTypeSuffixes() {
+		if strings.HasSuffix(name, "."+suffix) {
+			errs = append(errs, fmt.Errorf("name %q ends with the suffix of the %s type and would end with %s.%s", name, typ, suffix, suffix))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// ValidateRegistry checks the names of all the metrics of a registry with ValidateName,
+// and flags the names which would be written as the same measurement once sanitized.
+// It returns all the problems as NameErrors, or nil.
+func ValidateRegistry(reg metrics.Registry) error {
+	var names []string
+	reg.Each(func(name string, _ interface{}) {
+		names = append(names, name)
+	})
+	sort.Strings(names)
+
+	var errs NameErrors
+	sanitized := make(map[string]string, len(names))
+	for _, name := range names {
+		if err := ValidateName(name); err != nil {
+			errs = append(errs, err.(NameErrors)...)
+		}
+
+		s := DefaultSanitizer(name)
+		if other, ok := sanitized[s]; ok {
+			errs = append(errs, fmt.Errorf("names %q and %q would both be written as %q", other, name, s))
+			continue
+		}
+		sanitized[s] = name
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
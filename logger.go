@@ -0,0 +1,201 @@
+package influxdb
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogLevel is the verbosity of the messages.
+type LogLevel int
+
+// The log levels, from the most to the least verbose. LogDebug adds the encoded writes and the answers
+// of InfluxDB, LogWarn only keeps the failures.
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+// defaultLogRepeats is the default interval during which a repeated failure is logged once.
+const defaultLogRepeats = 5 * time.Minute
+
+// maxRepeated is the number of messages tracked by the repeats before the ones not seen for a window are forgotten.
+const maxRepeated = 100
+
+// maxLoggedPayload is the number of bytes of a write logged at the debug level.
+const maxLoggedPayload = 4096
+
+// Logger receives the messages of the reporters and clients. A *log.Logger is a Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// LevelLogger is a Logger which is also given the level of the messages.
+type LevelLogger interface {
+	Logger
+	Logf(level LogLevel, format string, v ...interface{})
+}
+
+// leveledLogger drops the messages under its level, and collapses the repeated send and ping failures
+// on the clock of its reporter or client.
+type leveledLogger struct {
+	Logger
+	level   LogLevel
+	repeats *repeats
+	clock   Clock
+}
+
+func newLeveledLogger() leveledLogger {
+	return leveledLogger{
+		Logger:  stdLogger{},
+		level:   LogInfo,
+		repeats: &repeats{window: defaultLogRepeats, seen: make(map[string]*repeated)},
+		clock:   realClock{},
+	}
+}
+
+func (l leveledLogger) enabled(level LogLevel) bool {
+	return level >= l.level
+}
+
+func (l leveledLogger) logf(level LogLevel, format string, v ...interface{}) {
+	if !l.enabled(level) {
+		return
+	}
+	if ll, ok := l.Logger.(LevelLogger); ok {
+		ll.Logf(level, format, v...)
+		return
+	}
+	l.Printf(format, v...)
+}
+
+// repeatedf logs a failure which repeats while InfluxDB is down, like a failed send or ping, at most once
+// per window of the repeats for the same message.
+func (l leveledLogger) repeatedf(level LogLevel, format string, v ...interface{}) {
+	if !l.enabled(level) {
+		return
+	}
+	n, elapsed, ok := l.repeats.check(fmt.Sprintf(format, v...), l.clock.Now())
+	if !ok {
+		return
+	}
+	if n > 0 {
+		format = withRepeats(format, n, elapsed)
+	}
+	l.logf(level, format, v...)
+}
+
+// repeats counts the failures logged with the same message, to log them at most once per window.
+type repeats struct {
+	mu     sync.Mutex
+	window time.Duration
+	seen   map[string]*repeated
+}
+
+type repeated struct {
+	logged time.Time
+	count  int
+}
+
+// check tells whether a failure must be logged, with the number of times it was dropped since
+// it was last logged and the time elapsed since then.
+func (rp *repeats) check(msg string, now time.Time) (int, time.Duration, bool) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	if rp.window <= 0 {
+		return 0, 0, true
+	}
+
+	s, ok := rp.seen[msg]
+	if !ok {
+		if len(rp.seen) >= maxRepeated {
+			rp.forget(now)
+		}
+		rp.seen[msg] = &repeated{logged: now}
+		return 0, 0, true
+	}
+	if now.Sub(s.logged) < rp.window {
+		s.count++
+		return 0, 0, false
+	}
+
+	n, elapsed := s.count, now.Sub(s.logged)
+	s.logged, s.count = now, 0
+	return n, elapsed, true
+}
+
+// forget drops the messages last logged more than a window ago. It must be called with the lock held.
+func (rp *repeats) forget(now time.Time) {
+	for msg, s := range rp.seen {
+		if now.Sub(s.logged) >= rp.window {
+			delete(rp.seen, msg)
+		}
+	}
+}
+
+// withRepeats adds the number of dropped repeats to the format of a failure, before its error.
+func withRepeats(format string, n int, elapsed time.Duration) string {
+	summary := fmt.Sprintf(", repeated %d times in the last %s", n, elapsed.Round(time.Second))
+	if i := strings.Index(format, ". err="); i >= 0 {
+		return format[:i] + summary + format[i:]
+	}
+	return format + summary
+}
+
+// truncate returns the start of a payload to log.
+func truncate(data []byte) string {
+	if len(data) <= maxLoggedPayload {
+		return string(data)
+	}
+	return fmt.Sprintf("%s... (%d more bytes)", data[:maxLoggedPayload], len(data)-maxLoggedPayload)
+}
+
+type stdLogger struct{}
+
+func (stdLogger) Printf(format string, v ...interface{}) {
+	log.Printf(format, v...)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Printf(string, ...interface{}) {}
+
+// NopLogger discards all the messages.
+var NopLogger Logger = nopLogger{}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// SlogLogger returns a Logger writing to l at the level of each message, with the error
+// in an err attribute for the messages reporting one.
+func SlogLogger(l *slog.Logger) Logger {
+	return slogLogger{l: l}
+}
+
+func (s slogLogger) Printf(format string, v ...interface{}) {
+	s.Logf(LogInfo, format, v...)
+}
+
+var slogLevels = map[LogLevel]slog.Level{
+	LogDebug: slog.LevelDebug,
+	LogInfo:  slog.LevelInfo,
+	LogWarn:  slog.LevelWarn,
+	LogError: slog.LevelError,
+}
+
+func (s slogLogger) Logf(level LogLevel, format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	if i := strings.Index(msg, ". err="); i >= 0 {
+		s.l.LogAttrs(context.Background(), slogLevels[level], msg[:i], slog.String("err", msg[i+len(". err="):]))
+		return
+	}
+	s.l.LogAttrs(context.Background(), slogLevels[level], msg)
+}
@@ -0,0 +1,90 @@
+package influxdb
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingLogger records the messages.
+type recordingLogger struct {
+	msgs []string
+}
+
+func (l *recordingLogger) Printf(format string, v ...interface{}) {
+	l.msgs = append(l.msgs, fmt.Sprintf(format, v...))
+}
+
+func TestRepeatedf(t *testing.T) {
+	type call struct {
+		after time.Duration
+		err   string
+		want  string
+	}
+	tests := []struct {
+		name  string
+		calls []call
+	}{
+		{
+			name: "collapsed",
+			calls: []call{
+				{err: "down", want: "unable to send. err=down"},
+				{after: time.Minute, err: "down"},
+				{after: time.Minute, err: "down"},
+				{after: 3 * time.Minute, err: "down", want: "unable to send, repeated 2 times in the last 5m0s. err=down"},
+			},
+		},
+		{
+			name: "other errors",
+			calls: []call{
+				{err: "down", want: "unable to send. err=down"},
+				{after: time.Second, err: "refused", want: "unable to send. err=refused"},
+				{after: time.Second, err: "down"},
+			},
+		},
+		{
+			name: "after the window",
+			calls: []call{
+				{err: "down", want: "unable to send. err=down"},
+				{after: 5 * time.Minute, err: "down", want: "unable to send. err=down"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			rec := &recordingLogger{}
+			l := newLeveledLogger()
+			l.Logger, l.clock = rec, clock
+
+			for i, c := range tt.calls {
+				clock.Advance(c.after)
+				n := len(rec.msgs)
+				l.repeatedf(LogError, "unable to send. err=%v", c.err)
+
+				got := ""
+				if len(rec.msgs) > n {
+					got = rec.msgs[n]
+				}
+				if got != c.want {
+					t.Errorf("call %d logged %q, want %q", i, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLogfNotCollapsed(t *testing.T) {
+	rec := &recordingLogger{}
+	l := newLeveledLogger()
+	l.Logger, l.clock = rec, newFakeClock()
+
+	for i := 0; i < 3; i++ {
+		l.logf(LogWarn, "field %s of measurement %s changed type", "value", "m")
+	}
+	if len(rec.msgs) != 3 || !strings.HasPrefix(rec.msgs[2], "field value") {
+		t.Errorf("got the messages %q, want 3", rec.msgs)
+	}
+}
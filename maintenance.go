@@ -0,0 +1,136 @@
+package influxdb
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MaintenanceMode tells the reporter what to do with the points during a maintenance window.
+type MaintenanceMode int
+
+const (
+	// PauseMaintenance drops the points of the metrics, whose state is still updated, so that the first
+	// send after the window only holds the changes since its end. The events and the other points are written.
+	PauseMaintenance MaintenanceMode = iota
+	// TagMaintenance writes the points with a maintenance=true tag.
+	TagMaintenance
+)
+
+// MaintenanceWindow is a recurring quiet period, like a deploy window or a chaos test, starting at Start
+// after midnight in Location, or UTC if nil, on the given weekdays, or every day if empty, for Duration.
+type MaintenanceWindow struct {
+	Weekdays []time.Weekday
+	Start    time.Duration
+	Duration time.Duration
+	Location *time.Location
+}
+
+// maxMaintenance is the longest maintenance window.
+const maxMaintenance = 7 * 24 * time.Hour
+
+func (w MaintenanceWindow) validate() error {
+	if w.Start < 0 || w.Start >= 24*time.Hour {
+		return fmt.Errorf("invalid maintenance window start %s", w.Start)
+	}
+	if w.Duration <= 0 || w.Duration > maxMaintenance {
+		return fmt.Errorf("invalid maintenance window duration %s", w.Duration)
+	}
+	return nil
+}
+
+// contains reports whether t is within an occurrence of the window, including one which started
+// on a previous day.
+func (w MaintenanceWindow) contains(t time.Time) bool {
+	loc := w.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	t = t.In(loc)
+
+	for days := 0; time.Duration(days)*24*time.Hour <= w.Start+w.Duration; days++ {
+		y, m, d := t.Date()
+		midnight := time.Date(y, m, d-days, 0, 0, 0, 0, loc)
+		if !w.on(midnight.Weekday()) {
+			continue
+		}
+		start := midnight.Add(w.Start)
+		if !t.Before(start) && t.Before(start.Add(w.Duration)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w MaintenanceWindow) on(day time.Weekday) bool {
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, wd := range w.Weekdays {
+		if wd == day {
+			return true
+		}
+	}
+	return false
+}
+
+// maintenance is the state of the maintenance of a reporter.
+type maintenance struct {
+	mu      sync.Mutex
+	manual  bool
+	windows []MaintenanceWindow
+	// active is whether the last send was in maintenance, to log the transitions
+	active bool
+}
+
+var maintenanceTags = map[string]string{"maintenance": "true"}
+
+// StartMaintenance starts a maintenance period, until EndMaintenance is called, during which the points
+// are handled according to WithMaintenanceMode. It may be called concurrently with Run.
+func (r *Reporter) StartMaintenance() {
+	r.maintenance.mu.Lock()
+	defer r.maintenance.mu.Unlock()
+	r.maintenance.manual = true
+}
+
+// EndMaintenance ends the maintenance period started by StartMaintenance. The maintenance windows still apply.
+func (r *Reporter) EndMaintenance() {
+	r.maintenance.mu.Lock()
+	defer r.maintenance.mu.Unlock()
+	r.maintenance.manual = false
+}
+
+// InMaintenance reports whether the reporter is in a maintenance period or window at t.
+func (r *Reporter) InMaintenance(t time.Time) bool {
+	r.maintenance.mu.Lock()
+	defer r.maintenance.mu.Unlock()
+	return r.maintenance.in(t)
+}
+
+func (m *maintenance) in(t time.Time) bool {
+	if m.manual {
+		return true
+	}
+	for _, w := range m.windows {
+		if w.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// inMaintenance reports whether a send is in maintenance, and logs the start and the end of the maintenance.
+func (r *Reporter) inMaintenance(now time.Time) bool {
+	r.maintenance.mu.Lock()
+	in := r.maintenance.in(now)
+	changed := in != r.maintenance.active
+	r.maintenance.active = in
+	r.maintenance.mu.Unlock()
+
+	if changed && in {
+		r.logger.logf(LogInfo, "starting the maintenance of the InfluxDB reporter")
+	} else if changed {
+		r.logger.logf(LogInfo, "ending the maintenance of the InfluxDB reporter")
+	}
+	return in
+}
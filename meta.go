@@ -0,0 +1,48 @@
+package influxdb
+
+import (
+	"time"
+)
+
+// meta describes a metric.
+type meta struct {
+	description string
+	unit        string
+	queued      bool
+}
+
+// Describe attaches a description and a unit, like "ms" or "bytes", to a metric of the registries.
+// They are written once to the metrics_meta measurement, tagged with the name of the metric,
+// so that dashboard and alert authors can discover what each series means.
+func (r *Reporter) Describe(name, description, unit string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.meta[name] = &meta{description: description, unit: unit}
+}
+
+// metaPoints queues a metrics_meta point with the points of WritePoints for each new description,
+// so that it is sent again until a write succeeds.
+func (r *Reporter) metaPoints(now time.Time) []Point {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for name, m := range r.meta {
+		if m.queued {
+			continue
+		}
+		m.queued = true
+
+		r.pending = append(r.pending, Point{
+			Measurement: r.prefix + "metrics_meta",
+			Tags: map[string]string{
+				"metric": name,
+			},
+			Fields: map[string]interface{}{
+				"description": m.description,
+				"unit":        m.unit,
+			},
+			Time: now,
+		})
+	}
+	return nil
+}
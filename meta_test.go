@@ -0,0 +1,31 @@
+package influxdb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestDescribe(t *testing.T) {
+	influx := newFakeInflux(t)
+	r := newTestReporter(t, influx.URL, metrics.NewRegistry())
+	r.Describe("latency", "time to answer a request", "ms")
+
+	// the description is written once, by the first send which succeeds
+	influx.failing("db", true)
+	if err := r.Send(); err == nil {
+		t.Fatal("the send succeeded, want it to fail")
+	}
+	influx.failing("db", false)
+	for i := 0; i < 2; i++ {
+		if err := r.Send(); err != nil {
+			t.Fatalf("unable to send. err=%v", err)
+		}
+	}
+
+	want := []string{`metrics_meta,metric=latency description="time to answer a request",unit="ms"`}
+	if got := influx.lines("db"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got the lines %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,148 @@
+// Package mqttsink provides a sink publishing the batches of an InfluxDB reporter to an MQTT topic,
+// encoded in the line protocol, for the embedded and IoT deployments which collect the metrics of
+// their devices over MQTT, with the mqtt_consumer input of Telegraf:
+//
+//	opts := mqtt.NewClientOptions().AddBroker("tcp://broker:1883").SetClientID("device-42")
+//	c := mqtt.NewClient(opts)
+//	if t := c.Connect(); t.Wait() && t.Error() != nil {
+//		return t.Error()
+//	}
+//	sink, err := mqttsink.New(c, "telegraf/{database}", mqttsink.WithQoS(1))
+//	rep, err := influxdb.New(metrics.DefaultRegistry, 10*time.Second, url, "mydb", "", "", influxdb.WithSinks(sink))
+//
+// The client is configured, connected and disconnected by the caller, which chooses the broker,
+// the credentials, TLS and the reconnection policy.
+package mqttsink
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	influxdb "github.com/vrischmann/go-metrics-influxdb"
+)
+
+// Sink publishes batches of points to an MQTT topic.
+type Sink struct {
+	client     mqtt.Client
+	topic      string
+	qos        byte
+	retained   bool
+	timeout    time.Duration
+	maxPayload int
+	buf        []byte
+}
+
+// Option configures a Sink.
+type Option func(*Sink)
+
+// WithQoS sets the quality of service of the messages: 0 (at most once, the default),
+// 1 (at least once) or 2 (exactly once).
+func WithQoS(qos byte) Option {
+	return func(s *Sink) {
+		s.qos = qos
+	}
+}
+
+// WithRetained makes the broker keep the last message of the topic for its new subscribers.
+func WithRetained(retained bool) Option {
+	return func(s *Sink) {
+		s.retained = retained
+	}
+}
+
+// WithTimeout sets how long to wait for the broker to acknowledge a message with a QoS of 1 or 2.
+// The default is 10 seconds.
+func WithTimeout(d time.Duration) Option {
+	return func(s *Sink) {
+		s.timeout = d
+	}
+}
+
+// WithMaxPayload splits the batches in messages of at most n bytes, cut between lines, for brokers
+// which limit the size of the messages. The default of 0 publishes each batch in one message.
+func WithMaxPayload(n int) Option {
+	return func(s *Sink) {
+		s.maxPayload = n
+	}
+}
+
+// New creates a sink publishing to the topic with a connected client. The {database} placeholder
+// of the topic is replaced by the database of each batch.
+func New(c mqtt.Client, topic string, opts ...Option) (*Sink, error) {
+	if topic == "" {
+		return nil, fmt.Errorf("empty MQTT topic")
+	}
+
+	s := &Sink{
+		client:  c,
+		topic:   topic,
+		timeout: 10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.qos > 2 {
+		return nil, fmt.Errorf("invalid MQTT QoS %d", s.qos)
+	}
+	if s.maxPayload < 0 {
+		return nil, fmt.Errorf("invalid MQTT max payload %d", s.maxPayload)
+	}
+
+	return s, nil
+}
+
+// Write publishes the points in the line protocol. The reporter calls it from its writer goroutine only.
+func (s *Sink) Write(database string, pts []influxdb.Point) error {
+	s.buf = influxdb.AppendLines(s.buf[:0], pts)
+	if len(s.buf) == 0 {
+		return nil
+	}
+
+	topic := strings.ReplaceAll(s.topic, "{database}", database)
+	for _, payload := range split(s.buf, s.maxPayload) {
+		if err := s.publish(topic, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Sink) publish(topic string, payload []byte) error {
+	// the client may keep the payload until it is sent, so it gets its own copy
+	t := s.client.Publish(topic, s.qos, s.retained, append([]byte(nil), payload...))
+	if !t.WaitTimeout(s.timeout) {
+		return fmt.Errorf("unable to publish metrics to MQTT topic %s. err=timed out after %s", topic, s.timeout)
+	}
+	if err := t.Error(); err != nil {
+		return fmt.Errorf("unable to publish metrics to MQTT topic %s. err=%v", topic, err)
+	}
+	return nil
+}
+
+// split cuts lines in chunks of at most max bytes, between lines. A longer line gets its own chunk.
+func split(lines []byte, max int) [][]byte {
+	if max == 0 || len(lines) <= max {
+		return [][]byte{lines}
+	}
+
+	var chunks [][]byte
+	for len(lines) > 0 {
+		n := len(lines)
+		if n > max {
+			n = bytes.LastIndexByte(lines[:max], '\n') + 1
+			if n == 0 {
+				n = bytes.IndexByte(lines, '\n') + 1
+				if n == 0 {
+					n = len(lines)
+				}
+			}
+		}
+		chunks = append(chunks, lines[:n])
+		lines = lines[n:]
+	}
+	return chunks
+}
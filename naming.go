@@ -0,0 +1,71 @@
+package influxdb
+
+// NameMapper returns the measurement and the tags of a registry entry of the given metric type,
+// or false to skip it.
+type NameMapper func(name string, metricType string) (measurement string, tags map[string]string, ok bool)
+
+func defaultTypeSuffixes() map[string]string {
+	return map[string]string{
+		TypeCounter:     "count",
+		TypeGauge:       "gauge",
+		TypeHistogram:   "histogram",
+		TypeMeter:       "meter",
+		TypeTimer:       "timer",
+		TypeHealthcheck: "healthcheck",
+		TypeEWMA:        "ewma",
+	}
+}
+
+// seriesKey identifies a registry entry in the cache of the series.
+type seriesKey struct {
+	host, name, typ string
+}
+
+// cachedSeries is the measurement and the tags of a registry entry. The tags are shared by its points
+// across sends, so they must never be modified.
+type cachedSeries struct {
+	measurement string
+	tags        map[string]string
+}
+
+// series returns the measurement and the tags of a registry entry, or false if it must be skipped.
+// Unless they come from a name mapper, they are computed once per entry.
+func (r *Reporter) series(host, name, typ string) (string, map[string]string, bool) {
+	if r.nameMapper != nil {
+		measurement, tags, ok := r.nameMapper(name, typ)
+		return r.prefix + measurement, tags, ok
+	}
+
+	key := seriesKey{host: host, name: name, typ: typ}
+	if v, ok := r.seriesCache.Load(key); ok {
+		s := v.(cachedSeries)
+		return s.measurement, s.tags, true
+	}
+
+	measurement, tags := r.composeSeries(host, name, typ)
+	r.seriesCache.Store(key, cachedSeries{measurement: measurement, tags: tags})
+
+	return measurement, tags, true
+}
+
+// composeSeries returns the measurement and the tags of a registry entry, from the templates,
+// the type prefixes and suffixes and the prefix.
+func (r *Reporter) composeSeries(host, name, typ string) (string, map[string]string) {
+	measurement := name
+	var tags map[string]string
+	if len(r.templates) > 0 {
+		measurement, tags = applyTemplates(r.templates, name)
+	}
+
+	if p := r.typePrefixes[typ]; p != "" {
+		measurement = p + r.separator + measurement
+	}
+	if r.typeTag {
+		tags = withTag(tags, "metric_type", typ)
+	} else if s := r.typeSuffixes[typ]; s != "" {
+		measurement = measurement + r.separator + s
+	}
+
+	// Prefix the namespace with the host
+	return r.prefix + host + measurement, tags
+}
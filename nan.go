@@ -0,0 +1,68 @@
+package influxdb
+
+import (
+	"math"
+
+	"github.com/influxdata/influxdb/client"
+)
+
+// NaNPolicy tells the reporter what to do with NaN and infinite field values, which InfluxDB rejects.
+type NaNPolicy int
+
+const (
+	// DropField removes the offending fields from the point. A point left without fields is dropped.
+	DropField NaNPolicy = iota
+	// DropPoint removes the whole point.
+	DropPoint
+	// ZeroField replaces the offending values with zero.
+	ZeroField
+)
+
+func isNaNOrInf(v interface{}) bool {
+	f, ok := v.(float64)
+	return ok && (math.IsNaN(f) || math.IsInf(f, 0))
+}
+
+// filterNaN applies the policy to every point and returns the points to keep.
+// The slice is filtered in place, but the fields of a point are copied before they are changed,
+// since they may be shared with the caller.
+func filterNaN(pts []client.Point, policy NaNPolicy) []client.Point {
+	res := pts[:0]
+
+	for _, pt := range pts {
+		keep := true
+		copied := false
+
+		for k, v := range pt.Fields {
+			if !isNaNOrInf(v) {
+				continue
+			}
+			if policy == DropPoint {
+				keep = false
+				break
+			}
+
+			if !copied {
+				fields := make(map[string]interface{}, len(pt.Fields))
+				for k, v := range pt.Fields {
+					fields[k] = v
+				}
+				pt.Fields = fields
+				copied = true
+			}
+
+			switch policy {
+			case DropField:
+				delete(pt.Fields, k)
+			case ZeroField:
+				pt.Fields[k] = float64(0)
+			}
+		}
+
+		if keep && len(pt.Fields) > 0 {
+			res = append(res, pt)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,58 @@
+package influxdb
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestFilterNaN(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy NaNPolicy
+		want   []map[string]interface{}
+	}{
+		{
+			name:   "drop field",
+			policy: DropField,
+			want:   []map[string]interface{}{{"ok": 1.5}, {"count": int64(2)}},
+		},
+		{
+			name:   "drop point",
+			policy: DropPoint,
+			want:   []map[string]interface{}{{"count": int64(2)}},
+		},
+		{
+			name:   "zero field",
+			policy: ZeroField,
+			want:   []map[string]interface{}{{"ok": 1.5, "bad": float64(0)}, {"inf": float64(0)}, {"count": int64(2)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := []map[string]interface{}{
+				{"ok": 1.5, "bad": math.NaN()},
+				{"inf": math.Inf(-1)},
+				{"count": int64(2)},
+			}
+			pts := make([]Point, len(fields))
+			for i, f := range fields {
+				pts[i] = Point{Measurement: "m", Fields: f}
+			}
+
+			var got []map[string]interface{}
+			for _, pt := range filterNaN(pts, tt.policy) {
+				got = append(got, pt.Fields)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got the fields %v, want %v", got, tt.want)
+			}
+
+			// the fields of the caller are left alone
+			if len(fields[0]) != 2 || !math.IsNaN(fields[0]["bad"].(float64)) || !math.IsInf(fields[1]["inf"].(float64), -1) {
+				t.Errorf("the fields of the caller were changed: %v", fields)
+			}
+		})
+	}
+}
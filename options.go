@@ -0,0 +1,792 @@
+package influxdb
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// Option configures a Reporter.
+type Option func(*Reporter)
+
+// WithTags adds tags to all the points. Tags set by the registry or the naming options take precedence.
+func WithTags(tags map[string]string) Option {
+	return func(r *Reporter) {
+		r.tags = tags
+	}
+}
+
+// WithTagHost prefixes every measurement with the host name.
+func WithTagHost(tagHost bool) Option {
+	return func(r *Reporter) {
+		r.tagHost = tagHost
+	}
+}
+
+// WithPrefix prepends a prefix, like "myservice.", to every measurement.
+func WithPrefix(prefix string) Option {
+	return func(r *Reporter) {
+		r.prefix = prefix
+	}
+}
+
+// WithSeparator sets the separator used between the host, the type prefixes, the metric name
+// and the type suffixes. The default is a dot; "_" or "/" are common alternatives.
+func WithSeparator(separator string) Option {
+	return func(r *Reporter) {
+		r.separator = separator
+	}
+}
+
+// WithSanitizer sets the function used to clean up measurements, field keys and tags.
+// A nil sanitizer disables sanitization.
+func WithSanitizer(s Sanitizer) Option {
+	return func(r *Reporter) {
+		r.sanitizer = s
+	}
+}
+
+// WithNaNPolicy sets what to do with NaN and infinite field values. The default is DropField.
+func WithNaNPolicy(p NaNPolicy) Option {
+	return func(r *Reporter) {
+		r.nanPolicy = p
+	}
+}
+
+// WithFloatFields writes every numeric field as a float, so that a field never changes type
+// between versions of an application. InfluxDB rejects writes which change the type of an existing field.
+func WithFloatFields(floatFields bool) Option {
+	return func(r *Reporter) {
+		r.floatFields = floatFields
+	}
+}
+
+// WithIntegerFields keeps some fields as integers when WithFloatFields is enabled.
+// A key is either a metric type, like "counter", or a metric type and a field, like "timer.count".
+//
+// Only fields which are already integers are kept. Switching a field from float to integer
+// on an existing database results in field type conflicts, so pick the keys once and stick to them.
+func WithIntegerFields(keys ...string) Option {
+	return func(r *Reporter) {
+		if r.integerFields == nil {
+			r.integerFields = make(map[string]bool, len(keys))
+		}
+		for _, k := range keys {
+			r.integerFields[k] = true
+		}
+	}
+}
+
+// WithSchemaCheck remembers the type each field is first written with and warns, or coerces the
+// value, when a later send would change it. InfluxDB rejects the whole batch in that case.
+func WithSchemaCheck(mode SchemaMode) Option {
+	return func(r *Reporter) {
+		if mode == SchemaOff {
+			r.schema = nil
+			return
+		}
+		r.schema = newSchema(mode)
+	}
+}
+
+// WithPercentiles sets the percentiles written for histograms and timers, between 0 and 1.
+// The field names default to p50 for 0.5, p999 for 0.999 and so on; names, if not nil,
+// must have one entry per percentile.
+func WithPercentiles(ps []float64, names []string) Option {
+	return func(r *Reporter) {
+		if names == nil {
+			names = percentileNames(ps)
+		}
+		r.percentiles = ps
+		r.percentileNames = names
+	}
+}
+
+// WithQuantilePoints writes each percentile of histograms and timers as its own point, with a quantile tag
+// and a single value field, instead of one field per percentile. This is the layout used by Prometheus.
+func WithQuantilePoints(quantilePoints bool) Option {
+	return func(r *Reporter) {
+		r.quantilePoints = quantilePoints
+	}
+}
+
+// WithDurationUnit sets the unit of the durations written for timers: time.Nanosecond, time.Microsecond,
+// time.Millisecond or time.Second. The default is milliseconds. When set, timers also get a unit field
+// holding the name of the unit, like "us".
+//
+// min and max are integers, so they are truncated to the unit.
+func WithDurationUnit(unit time.Duration) Option {
+	return func(r *Reporter) {
+		r.durationUnit = unit
+		r.durationUnitField = true
+	}
+}
+
+// WithRateUnit sets the unit of the rates written for meters and timers. The default is per second;
+// use time.Minute for events per minute, or the reporting interval for events per interval.
+func WithRateUnit(unit time.Duration) Option {
+	return func(r *Reporter) {
+		r.rateUnit = unit
+	}
+}
+
+// WithFieldNamer sets the function used to rename the fields written,
+// for example FieldNames(map[string]string{"m1": "rate_1m"}).
+func WithFieldNamer(namer FieldNamer) Option {
+	return func(r *Reporter) {
+		r.fieldNamer = namer
+	}
+}
+
+// WithTemplates splits dotted metric names into a measurement and tags, like the graphite templates of Telegraf.
+// A template is "[filter ]template", for example "api.* service.resource.method.measurement" which turns
+// api.users.get.latency into the measurement latency with the tags service=api, resource=users and method=get.
+// "measurement*" captures the rest of the name and empty parts are skipped. The first matching template is used.
+func WithTemplates(templates ...string) Option {
+	return func(r *Reporter) {
+		r.templateSpecs = append(r.templateSpecs, templates...)
+	}
+}
+
+// WithNameMapper sets the function turning registry entries into InfluxDB series. It replaces the default naming:
+// the host prefix, the templates and the type suffixes are not applied.
+func WithNameMapper(mapper NameMapper) Option {
+	return func(r *Reporter) {
+		r.nameMapper = mapper
+	}
+}
+
+// WithTypeSuffixes replaces the suffixes appended to measurements, by metric type.
+// The defaults are count, gauge, histogram, meter, timer, healthcheck and ewma; an empty suffix omits it.
+func WithTypeSuffixes(suffixes map[string]string) Option {
+	return func(r *Reporter) {
+		for typ, s := range suffixes {
+			r.typeSuffixes[typ] = s
+		}
+	}
+}
+
+// WithoutTypeSuffixes omits the suffixes appended to measurements.
+func WithoutTypeSuffixes() Option {
+	return func(r *Reporter) {
+		r.typeSuffixes = map[string]string{}
+	}
+}
+
+// WithTypePrefixes sets prefixes prepended to measurements, by metric type.
+func WithTypePrefixes(prefixes map[string]string) Option {
+	return func(r *Reporter) {
+		r.typePrefixes = prefixes
+	}
+}
+
+// WithFlatLayout writes each metric as a single point whose measurement is the metric name, without
+// type suffix or prefix, and with all the stats as fields. This is the layout of vrischmann/go-metrics-influxdb,
+// so dashboards and continuous queries built for it keep working. Options given after it still apply.
+func WithFlatLayout() Option {
+	return func(r *Reporter) {
+		r.typeSuffixes = map[string]string{}
+		r.typePrefixes = nil
+		r.quantilePoints = false
+	}
+}
+
+// WithTypeTag writes the metric type as a metric_type tag, like metric_type=timer, instead of a measurement suffix.
+func WithTypeTag(typeTag bool) Option {
+	return func(r *Reporter) {
+		r.typeTag = typeTag
+	}
+}
+
+// WithInclude only reports the metrics whose name matches one of the regular expressions.
+func WithInclude(patterns ...string) Option {
+	return func(r *Reporter) {
+		r.includePatterns = append(r.includePatterns, patterns...)
+	}
+}
+
+// WithExclude doesn't report the metrics whose name matches one of the regular expressions.
+// Exclusions take precedence over inclusions.
+func WithExclude(patterns ...string) Option {
+	return func(r *Reporter) {
+		r.excludePatterns = append(r.excludePatterns, patterns...)
+	}
+}
+
+// WithRoutes sends the metrics whose names match the pattern of a route to its database, instead of
+// the database of the reporter. The first matching route wins; the database of a source takes precedence.
+func WithRoutes(routes ...Route) Option {
+	return func(r *Reporter) {
+		r.routeSpecs = append(r.routeSpecs, routes...)
+	}
+}
+
+// WithFilter only reports the registry entries for which the filter returns true.
+// It is called after the include and exclude patterns.
+func WithFilter(filter Filter) Option {
+	return func(r *Reporter) {
+		r.filter = filter
+	}
+}
+
+// WithoutTypes doesn't report the metrics of the given types, like TypeMeter.
+func WithoutTypes(types ...string) Option {
+	return func(r *Reporter) {
+		if r.disabledTypes == nil {
+			r.disabledTypes = make(map[string]bool, len(types))
+		}
+		for _, typ := range types {
+			r.disabledTypes[typ] = true
+		}
+	}
+}
+
+// WithTypes only reports the metrics of the given types, like TypeCounter and TypeTimer.
+func WithTypes(types ...string) Option {
+	return func(r *Reporter) {
+		r.disabledTypes = make(map[string]bool, len(metricTypes))
+		for _, typ := range metricTypes {
+			r.disabledTypes[typ] = true
+		}
+		for _, typ := range types {
+			delete(r.disabledTypes, typ)
+		}
+	}
+}
+
+// WithSkipPolicy skips the meters, timers and histograms which have never been updated (SkipZeroCount)
+// or whose count didn't change since the last send (SkipUnchangedCount), instead of writing the same point
+// every interval.
+func WithSkipPolicy(p SkipPolicy) Option {
+	return func(r *Reporter) {
+		r.skipPolicy = p
+	}
+}
+
+// WithOnlyChanged only reports counters and gauges when their value changed since the last time they were reported.
+// They are still reported every heartbeat intervals so that the series don't look dead; a heartbeat of 0 disables it.
+func WithOnlyChanged(heartbeat int) Option {
+	return func(r *Reporter) {
+		r.onlyChanged = true
+		r.heartbeat = heartbeat
+	}
+}
+
+// WithCounterDeltaField adds the change of each counter since the last send as a delta field,
+// next to the cumulative count in the value field. WithCounterDeltas takes precedence.
+func WithCounterDeltaField() Option {
+	return func(r *Reporter) {
+		r.deltaField = true
+	}
+}
+
+// WithCounterDeltas writes the change of each counter since the last send as the value field,
+// instead of the cumulative count. If cumulative is true, the cumulative count is also written as a count field.
+func WithCounterDeltas(cumulative bool) Option {
+	return func(r *Reporter) {
+		r.counterDeltas = true
+		r.counterCumulative = cumulative
+	}
+}
+
+// WithClearOnFlush clears the counters and histograms, and any other metric with a Clear method,
+// after each successful send, so that each interval only reflects what happened during it, like statsd.
+// Meters and timers of go-metrics can't be cleared.
+func WithClearOnFlush(clearOnFlush bool) Option {
+	return func(r *Reporter) {
+		r.clearOnFlush = clearOnFlush
+	}
+}
+
+// WithWindowedStats adds the window_count field to histograms and timers, the number of values recorded
+// since the last send, and the window_sum and window_mean fields to the ones created with NewWindowedHistogram
+// and NewWindowedTimer, which keep the sum of all their values: the sum of the others only covers their
+// reservoir. The lifetime stats of exponentially decaying samples react slowly,
+// which makes them a poor fit for alerting.
+func WithWindowedStats(windowed bool) Option {
+	return func(r *Reporter) {
+		r.windowed = windowed
+	}
+}
+
+// WithBuckets adds the cumulative number of sampled values lower than or equal to each bound to histograms
+// and sliding window timers, as le_<bound> fields plus a le_inf field, like Prometheus histograms.
+// Timer bounds are in the duration unit. See ExponentialBuckets to build the bounds.
+func WithBuckets(bounds []float64) Option {
+	return func(r *Reporter) {
+		r.bucketBounds = bounds
+	}
+}
+
+// WithBucketPoints writes each bucket of WithBuckets as its own point, with a le tag and a single count field.
+func WithBucketPoints(bucketPoints bool) Option {
+	return func(r *Reporter) {
+		r.bucketPoints = bucketPoints
+	}
+}
+
+// WithSLOThresholds adds an under_<threshold> field to timers, like under_100ms=0.97, or under_1500us for 1.5ms,
+// holding the share of sampled durations lower than or equal to each threshold. The thresholds are given by timer name;
+// the ones of the empty name apply to every other timer. The share is exact for sliding window timers
+// and estimated from the percentiles, to 0.1%, for other timers.
+func WithSLOThresholds(thresholds map[string][]time.Duration) Option {
+	return func(r *Reporter) {
+		r.sloThresholds = thresholds
+	}
+}
+
+// WithSubInterval collects the value of every gauge at each sub interval, like every second, and adds the min,
+// max and avg of the collected values to the gauge points written at each interval. This keeps a better fidelity
+// than a single instant value without writing more points.
+func WithSubInterval(d time.Duration) Option {
+	return func(r *Reporter) {
+		r.subInterval = d
+	}
+}
+
+// WithFlushInterval buffers the points collected at each interval, with their own timestamps, and writes them
+// in a single request at each flush interval. This gives a high resolution, like one second, without sending
+// one request per second.
+func WithFlushInterval(d time.Duration) Option {
+	return func(r *Reporter) {
+		r.flushInterval = d
+	}
+}
+
+// WithRegistry attaches one more registry to the reporter. The names of its metrics are prefixed with prefix,
+// which can be empty.
+func WithRegistry(reg metrics.Registry, prefix string) Option {
+	return WithTaggedRegistry(reg, prefix, nil)
+}
+
+// WithTaggedRegistry attaches one more registry to the reporter, like WithRegistry, and adds tags
+// to all its points, like component=cache.
+func WithTaggedRegistry(reg metrics.Registry, prefix string, tags map[string]string) Option {
+	return WithSource(Source{Registry: reg, Prefix: prefix, Tags: tags})
+}
+
+// WithSource attaches one more registry to the reporter, with its own prefix, tags and database.
+func WithSource(src Source) Option {
+	return func(r *Reporter) {
+		r.sources = append(r.sources, &src)
+	}
+}
+
+// WithClientOptions configures the client created by the reporter. They are ignored with WithClient.
+func WithClientOptions(opts ...ClientOption) Option {
+	return func(r *Reporter) {
+		r.clientOptions = append(r.clientOptions, opts...)
+	}
+}
+
+// WithClient makes the reporter use a client shared with other reporters, instead of creating its own.
+func WithClient(c *Client) Option {
+	return func(r *Reporter) {
+		r.client = c
+	}
+}
+
+// WithCollector calls a collector before each send.
+func WithCollector(c Collector) Option {
+	return func(r *Reporter) {
+		r.collectors = append(r.collectors, c)
+	}
+}
+
+// WithRuntimeMetrics registers the Go runtime memory and GC stats into the registry of the reporter
+// and captures them before each send.
+func WithRuntimeMetrics() Option {
+	return func(r *Reporter) {
+		r.runtime = true
+	}
+}
+
+// WithBuildInfo writes a build_info point, tagged with the version, revision and date of the build
+// and the Go version, at each send or only at the first one. It tells which version runs where.
+func WithBuildInfo(info BuildInfo, once bool) Option {
+	return func(r *Reporter) {
+		r.extras = append(r.extras, r.buildInfoPoints(info, once))
+	}
+}
+
+// WithHostInfo writes a host_info point, tagged with the OS, the architecture and the host name and holding
+// the number of CPUs and the PID, at the first send and then every n sends. If n is 0 it is only written once.
+func WithHostInfo(n int) Option {
+	return func(r *Reporter) {
+		r.extras = append(r.extras, r.hostInfoPoints(n))
+	}
+}
+
+// WithHeartbeat writes a heartbeat point at each send, tagged with the host name and the global tags,
+// even when every metric is filtered or unchanged, so that a deadman alert can tell an instance stopped reporting.
+func WithHeartbeat() Option {
+	return func(r *Reporter) {
+		r.extras = append(r.extras, r.heartbeatPoints())
+	}
+}
+
+// WithEventMeasurement sets the measurement of the points written by Event. The default is events.
+func WithEventMeasurement(measurement string) Option {
+	return func(r *Reporter) {
+		r.eventMeasurement = measurement
+	}
+}
+
+// WithUnitTags adds a unit tag, like unit=ms, to the points of the metrics whose unit is known: from the units map,
+// by metric name, then from Describe, then, if conventions is true, from the last part of the name
+// (bytes, ns, us, ms, seconds, percent or ops). Timers default to the duration unit.
+func WithUnitTags(units map[string]string, conventions bool) Option {
+	return func(r *Reporter) {
+		r.unitTags = true
+		r.units = units
+		r.unitConventions = conventions
+	}
+}
+
+// WithInventory writes a registry_inventory point at each send, holding the number of registry entries
+// per metric type, their total and the number of points written, to spot registries growing without bound.
+func WithInventory(inventory bool) Option {
+	return func(r *Reporter) {
+		r.inventory = inventory
+	}
+}
+
+// WithStartupCheck makes New ping InfluxDB and fail if it is not reachable, after retrying up to retries times
+// with an exponential backoff starting at backoff. This catches misconfigurations at deploy time.
+func WithStartupCheck(retries int, backoff time.Duration) Option {
+	return func(r *Reporter) {
+		r.startupCheck = true
+		r.startupRetries = retries
+		r.startupBackoff = backoff
+	}
+}
+
+// WithDatabaseCheck makes New fail if a database written to doesn't exist, instead of failing every send.
+// If create is true, missing databases are created instead.
+func WithDatabaseCheck(create bool) Option {
+	return func(r *Reporter) {
+		r.databaseCheck = true
+		r.createDatabase = create
+	}
+}
+
+// WithOverlapPolicy sets what to do with a send due while the send queue is full.
+// The default is QueueOverlapping.
+func WithOverlapPolicy(p OverlapPolicy) Option {
+	return func(r *Reporter) {
+		r.overlapPolicy = p
+	}
+}
+
+// WithSendQueue sets how many sends can wait for the writer goroutine, behind the one it writes. The default is 1.
+func WithSendQueue(n int) Option {
+	return func(r *Reporter) {
+		r.queueSize = n
+	}
+}
+
+// WithWorkers sets the number of goroutines building the points of the metrics at each send. The default is 1.
+func WithWorkers(n int) Option {
+	return func(r *Reporter) {
+		r.workers = n
+	}
+}
+
+// WithMaxBatchSize writes the points of a send as soon as n of them are built for a database,
+// instead of building all of them first, which bounds the memory used by huge registries.
+// It has no effect with a flush interval.
+func WithMaxBatchSize(n int) Option {
+	return func(r *Reporter) {
+		r.maxBatch = n
+	}
+}
+
+// WithAlignedIntervals sends at each multiple of the interval, like every minute on the minute,
+// with the boundary as the time of the points, so that the points of many instances line up.
+func WithAlignedIntervals() Option {
+	return func(r *Reporter) {
+		r.aligned = true
+	}
+}
+
+// WithStartupJitter delays the sends by a random fraction of the interval, picked once and kept when
+// SetInterval changes it, so that many instances started together don't write at the same time.
+// Aligned sends stay timestamped with the interval boundaries.
+func WithStartupJitter() Option {
+	return func(r *Reporter) {
+		r.jitter = true
+	}
+}
+
+// WithImmediateSend sends the metrics as soon as Run is called, instead of after the first interval.
+func WithImmediateSend() Option {
+	return func(r *Reporter) {
+		r.immediate = true
+	}
+}
+
+// WithWarmup drops the metric points of the sends due during d after Run is called, so that the spikes
+// of the startup don't trigger alerts. The points of WritePoints and Event and the info points are still written.
+func WithWarmup(d time.Duration) Option {
+	return func(r *Reporter) {
+		r.warmup = d
+	}
+}
+
+// WithTypeIntervals reports the metrics of some types less often, like TypeTimer every minute while
+// the counters are reported at each interval. The intervals must be multiples of the interval.
+func WithTypeIntervals(intervals map[string]time.Duration) Option {
+	return func(r *Reporter) {
+		r.typeIntervals = intervals
+	}
+}
+
+// WithTiers assigns the metrics to tiers, reported less often or with a probability, by the first tier
+// with a pattern matching their name. The other metrics are reported at every send.
+func WithTiers(tiers ...Tier) Option {
+	return func(r *Reporter) {
+		r.tiers = append(r.tiers, tiers...)
+	}
+}
+
+// WithIdleSuppression skips the metric points of the sends when no metric changed since the last one and no points
+// are pending, at most maxSkips times in a row, so that a mostly idle deployment writes its metrics about once every
+// maxSkips+1 intervals. The heartbeat, build and host info, inventory and metadata points are still written.
+// A metric changes when its count or value does; the other metric types never count as activity.
+func WithIdleSuppression(maxSkips int) Option {
+	return func(r *Reporter) {
+		r.maxIdleSkips = maxSkips
+	}
+}
+
+// WithIntervalTag adds the interval between the points of each metric to its points, as a tag with the given key,
+// like interval=10s, so that the queries normalize the counts to rates whatever the configuration of each service.
+// The interval accounts for WithTypeIntervals and WithTiers.
+func WithIntervalTag(key string) Option {
+	return func(r *Reporter) {
+		r.intervalTag = key
+	}
+}
+
+// WithIntervalField adds the interval between the points of each metric to its points, in milliseconds,
+// as a field with the given key, like interval_ms. The interval accounts for WithTypeIntervals and WithTiers.
+func WithIntervalField(key string) Option {
+	return func(r *Reporter) {
+		r.intervalField = key
+	}
+}
+
+// WithMaintenanceWindows adds recurring maintenance windows, like deploy windows or chaos tests, during which
+// the points are handled according to WithMaintenanceMode, so that they don't pollute the baselines or trigger alerts.
+func WithMaintenanceWindows(windows ...MaintenanceWindow) Option {
+	return func(r *Reporter) {
+		r.maintenance.windows = append(r.maintenance.windows, windows...)
+	}
+}
+
+// WithMaintenanceMode sets what to do with the points during a maintenance window or a maintenance started
+// with StartMaintenance. The default is PauseMaintenance.
+func WithMaintenanceMode(mode MaintenanceMode) Option {
+	return func(r *Reporter) {
+		r.maintenanceMode = mode
+	}
+}
+
+// WithAdaptiveInterval spaces out the writes while they fail, doubling the effective interval
+// after each failure up to max, and goes back to the normal interval after a successful write.
+func WithAdaptiveInterval(max time.Duration) Option {
+	return func(r *Reporter) {
+		r.maxInterval = max
+	}
+}
+
+// WithFlushSignals makes the reporter send the metrics when the process receives one of the signals,
+// like syscall.SIGUSR1, as Flush does.
+func WithFlushSignals(sigs ...os.Signal) Option {
+	return func(r *Reporter) {
+		r.flushSignals = sigs
+	}
+}
+
+// WithErrorHandler calls h with the error of each failed send, and of each failed ping of the client
+// unless the reporter is given one with WithClient. h is called from the reporter goroutines, so it
+// must not block, and may be called concurrently.
+func WithErrorHandler(h func(error)) Option {
+	return func(r *Reporter) {
+		r.errorHandler = h
+	}
+}
+
+// WithCSVExport writes the batches as CSV to w, with EncodeCSV, instead of writing them to InfluxDB,
+// for ad-hoc analysis or environments without a time series database. Each batch starts with a header,
+// since its columns depend on its tags and fields. The client isn't used, so disable its pings with
+// WithClientOptions(WithPingInterval(0)).
+func WithCSVExport(w io.Writer) Option {
+	return func(r *Reporter) {
+		r.csv = w
+	}
+}
+
+// WithTruncatedTimestamps truncates the time of the points of each send to the interval, like aligned sends,
+// so that the points of every instance line up.
+func WithTruncatedTimestamps() Option {
+	return func(r *Reporter) {
+		r.truncate = true
+	}
+}
+
+// WithClock sets the clock of the reporter, and of its client unless it is given one with WithClient:
+// the time of the points, the tickers of the sends and flushes and the waits of the pings.
+// The default is the system clock; tests can give a fake one to drive the sends deterministically.
+func WithClock(c Clock) Option {
+	return func(r *Reporter) {
+		r.clock = c
+	}
+}
+
+// WithConnStateHandler calls h when the state of the connection to InfluxDB changes between Connected,
+// Degraded and Disconnected, given the outcome of the last write and the last ping of the client, to surface
+// an unreachable metrics backend in the health system of the application. The state is computed after
+// each write, and h runs in the goroutine of the reporter, so it must not block.
+func WithConnStateHandler(h func(from, to ConnState)) Option {
+	return func(r *Reporter) {
+		r.connStateHandler = h
+	}
+}
+
+// WithMaxFailures calls h once n consecutive sends failed, with the number of failures and the last error,
+// to fail loudly instead of retrying silently. It is called again if the sends fail n times in a row after
+// a success. If h is nil, the process exits with status 1 instead.
+func WithMaxFailures(n int, h func(failures int, err error)) Option {
+	return func(r *Reporter) {
+		r.maxFailures = n
+		r.failuresHandler = h
+	}
+}
+
+// WithWatchdog supervises the reporting loop: it is restarted if it panics, and a loop which made no progress
+// for twice the interval, stuck in a collector or a middleware for example, is logged and passed to the error
+// handler. A panic of a write, in a sink, the send hook or a codec, fails the write like an error of InfluxDB.
+// Restarts and stalls are counted by the self-metrics.
+func WithWatchdog() Option {
+	return func(r *Reporter) {
+		r.watchdog = true
+	}
+}
+
+// WithShards spreads the series across several InfluxDB instances instead of writing them to the client
+// of the reporter, by consistent hashing of the value of the tag by, or of the measurement if by is empty
+// or the point doesn't have the tag. The series of a removed instance move to the others, the other series stay.
+// The client of the reporter is still used for the database checks.
+func WithShards(by string, clients ...*Client) Option {
+	return func(r *Reporter) {
+		r.shardBy = by
+		r.shardClients = clients
+	}
+}
+
+// WithTenantDatabases writes the points with the given tag, like tenant, to the database named after its value
+// with the given prefix, and the others to their usual database, so that the metrics of each customer are isolated.
+// The tenant databases must exist, since they aren't known when the databases are checked.
+func WithTenantDatabases(tag, prefix string) Option {
+	return func(r *Reporter) {
+		r.tenantTag = tag
+		r.tenantPrefix = prefix
+	}
+}
+
+// WithRegistryDiff tracks the names of the metrics from one send to the next, and logs the metrics which appear
+// and vanish, to catch accidental unregistrations, typos and leaks of dynamically generated names. h, if not nil,
+// is called with the changes, in the goroutine of the reporter. With WithSelfMetrics, the changes are counted
+// in metrics_added and metrics_removed.
+func WithRegistryDiff(h func(RegistryDiff)) Option {
+	return func(r *Reporter) {
+		r.registryDiff = true
+		r.diffHandler = h
+	}
+}
+
+// WithSinks gives a copy of every batch to the sinks, like the Prometheus remote write sink
+// of the promwrite package or the MQTT sink of the mqttsink package, along with writing it to InfluxDB.
+func WithSinks(sinks ...Sink) Option {
+	return func(r *Reporter) {
+		r.sinks = append(r.sinks, sinks...)
+	}
+}
+
+// WithSendHook calls hook before each write of a batch of points to a database, and the function it returns,
+// if not nil, once the write is done, with its duration and error. The hooks run in the writer goroutine,
+// so a slow hook delays the writes but never the collection of the metrics.
+func WithSendHook(hook func(SendInfo) func(SendInfo)) Option {
+	return func(r *Reporter) {
+		r.sendHook = hook
+	}
+}
+
+// WithMiddlewares adds middlewares transforming the points of each batch before they are sanitized
+// and written, in the order they are given.
+func WithMiddlewares(mws ...Middleware) Option {
+	return func(r *Reporter) {
+		r.middlewares = append(r.middlewares, mws...)
+	}
+}
+
+// WithSelfMetrics registers the metrics of the reporter into its registry, under the given prefix:
+// the points sent, the bytes written, the duration of the writes, the consecutive failures,
+// the queued sends and the points buffered with a flush interval.
+func WithSelfMetrics(prefix string) Option {
+	return func(r *Reporter) {
+		r.selfPrefix = prefix
+	}
+}
+
+// WithExpvar publishes the Status of the reporter under the given expvar name, served on /debug/vars.
+// Names can only be published once, so New fails if the name is taken.
+func WithExpvar(name string) Option {
+	return func(r *Reporter) {
+		r.expvarName = name
+	}
+}
+
+// WithHealthcheck registers a healthcheck under the given name into the registry of the reporter,
+// unhealthy while the writes to InfluxDB or the pings of the client fail.
+func WithHealthcheck(name string) Option {
+	return func(r *Reporter) {
+		r.healthName = name
+	}
+}
+
+// WithEcho writes a copy of the line protocol of every write to w, like os.Stderr, before writing it to InfluxDB.
+func WithEcho(w io.Writer) Option {
+	return func(r *Reporter) {
+		r.echo = w
+	}
+}
+
+// WithLogger sets the logger of the reporter, and of its client unless it is given one with WithClient.
+// The default logs with the log package; NopLogger discards the messages and SlogLogger writes them to a slog.Logger.
+func WithLogger(l Logger) Option {
+	return func(r *Reporter) {
+		r.logger.Logger = l
+	}
+}
+
+// WithLogLevel sets the verbosity of the reporter, and of its client unless it is given one with WithClient.
+// The default is LogInfo; LogDebug logs the encoded writes, truncated, and the answers of InfluxDB,
+// and LogWarn only logs the failures.
+func WithLogLevel(level LogLevel) Option {
+	return func(r *Reporter) {
+		r.logger.level = level
+	}
+}
+
+// WithLogRepeats logs a send or a ping failing with the same error at every interval while InfluxDB is down
+// at most once per window, with the number of times it was repeated. It applies to the client too unless
+// the reporter is given one with WithClient. The default is 5 minutes; 0 logs every failure.
+func WithLogRepeats(window time.Duration) Option {
+	return func(r *Reporter) {
+		r.logger.repeats.window = window
+	}
+}
@@ -0,0 +1,66 @@
+package influxdb
+
+import (
+	"time"
+)
+
+// WritePoints writes points which don't come from a registry with the next send, in the same batches
+// as the metrics, to the database of the reporter. They get the global tags and are sanitized like the
+// others, and the points without a time get the time of the send. If the write fails, they are sent
+// again with the next one, keeping the last maxPending points. It may be called concurrently with Run.
+func (r *Reporter) WritePoints(pts ...Point) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending = append(r.pending, pts...)
+}
+
+// Event writes an event, like a deployment, a configuration change or a restart, to the events measurement
+// with the next send, at the time it happened. The point holds the title and text fields and the given tags,
+// to be shown as Grafana annotations. It may be called concurrently with Run.
+func (r *Reporter) Event(title, text string, tags map[string]string) {
+	r.WritePoints(Point{
+		Measurement: r.prefix + r.eventMeasurement,
+		Tags:        tags,
+		Fields: map[string]interface{}{
+			"title": title,
+			"text":  text,
+		},
+		Time: r.clock.Now(),
+	})
+}
+
+// maxPending is the number of points of WritePoints kept while the writes fail.
+const maxPending = 10000
+
+// pendingPoints returns the points given to WritePoints since the last send.
+func (r *Reporter) pendingPoints(now time.Time) []Point {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pts := r.pending
+	r.pending = nil
+	for i := range pts {
+		if pts[i].Time.IsZero() {
+			pts[i].Time = now
+		}
+	}
+	return pts
+}
+
+// retain keeps the points of a failed write for the next send, before the ones given since,
+// dropping the oldest ones beyond maxPending.
+func (r *Reporter) retain(pts []Point) {
+	if len(pts) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pts = append(pts, r.pending...)
+	if n := len(pts) - maxPending; n > 0 {
+		pts = pts[n:]
+		r.dropped(droppedPoints, int64(n))
+	}
+	r.pending = pts
+}
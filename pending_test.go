@@ -0,0 +1,59 @@
+package influxdb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestWritePointsRetry(t *testing.T) {
+	influx := newFakeInflux(t)
+	r := newTestReporter(t, influx.URL, metrics.NewRegistry(), WithTenantDatabases("tenant", "t_"))
+
+	send := func() {
+		t.Helper()
+		j, err := r.send()
+		if err != nil {
+			t.Fatalf("unable to send. err=%v", err)
+		}
+		r.writeJob(j)
+	}
+
+	influx.failing("t_b", true)
+	r.WritePoints(
+		Point{Measurement: "deploy", Tags: map[string]string{"tenant": "a"}, Fields: map[string]interface{}{"value": int64(1)}},
+		Point{Measurement: "deploy", Tags: map[string]string{"tenant": "b"}, Fields: map[string]interface{}{"value": int64(2)}},
+	)
+	send()
+	influx.failing("t_b", false)
+	send()
+	send()
+
+	// the point of the database whose write succeeded isn't written again
+	want := map[string][]string{
+		"t_a": {"deploy,tenant=a value=1i"},
+		"t_b": {"deploy,tenant=b value=2i"},
+	}
+	for db, lines := range want {
+		if got := influx.lines(db); !reflect.DeepEqual(got, lines) {
+			t.Errorf("got the lines %q in %s, want %q", got, db, lines)
+		}
+	}
+}
+
+func TestWritePointsRetryBound(t *testing.T) {
+	r := newTestReporter(t, unreachable, metrics.NewRegistry())
+
+	pts := make([]Point, maxPending+10)
+	for i := range pts {
+		pts[i] = Point{Measurement: "m", Fields: map[string]interface{}{"value": int64(i)}}
+	}
+	r.retain(pts)
+	if len(r.pending) != maxPending || r.pending[0].Fields["value"] != int64(10) {
+		t.Errorf("kept %d points from %v, want the last %d", len(r.pending), r.pending[0].Fields["value"], maxPending)
+	}
+	if got := r.Status().Dropped; got != 10 {
+		t.Errorf("dropped %d points, want 10", got)
+	}
+}
@@ -0,0 +1,170 @@
+package influxdb
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// OverlapPolicy tells the reporter what to do with a send due while the send queue is full.
+type OverlapPolicy int
+
+const (
+	// QueueOverlapping runs the send once the queue has room. At most one send waits for it.
+	QueueOverlapping OverlapPolicy = iota
+	// SkipOverlapping skips the send. The metrics are reported by the next one.
+	SkipOverlapping
+)
+
+// SendInfo describes a write of a batch of points to a database, for the hook of WithSendHook.
+// Duration and Err are only set once the write is done.
+type SendInfo struct {
+	Database string
+	Points   int
+	Bytes    int
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// job is a batch of points waiting to be written, with the metrics to clear once it is,
+// the field maps to recycle and the points of WritePoints, by database, to send again if their write fails.
+type job struct {
+	bs      batches
+	cleared []clearer
+	fields  []map[string]interface{}
+	pending batches
+}
+
+// pipeline feeds the writer goroutine, so that a slow InfluxDB never delays the reporter loop.
+type pipeline struct {
+	jobs      chan job
+	done      chan error
+	queued    func() (job, error)
+	closeOnce sync.Once
+}
+
+// close stops the writer once it wrote the queued jobs.
+func (p *pipeline) close() {
+	p.closeOnce.Do(func() {
+		close(p.jobs)
+	})
+}
+
+func newPipeline(size int) *pipeline {
+	return &pipeline{
+		jobs: make(chan job, size),
+		// the writer must never block on done when the loop is busy queuing
+		done: make(chan error, size+1),
+	}
+}
+
+// writer writes the jobs until the queue is closed.
+func (r *Reporter) writer(p *pipeline) {
+	for j := range p.jobs {
+		p.done <- r.writeJob(j)
+	}
+	close(p.done)
+}
+
+// writeJob writes a job, and clears its metrics once it is written. With the watchdog, a panic of a write,
+// like in a sink, the send hook or a codec, fails the job instead of killing the process.
+func (r *Reporter) writeJob(j job) (err error) {
+	defer release(j)
+	if r.watchdog {
+		defer func() {
+			if v := recover(); v != nil {
+				r.logger.logf(LogError, "the writer panicked, dropping the write. err=%v\n%s", v, debug.Stack())
+				err = fmt.Errorf("the writer panicked. err=%v", v)
+				r.status.wrote(0, err, r.clock.Now())
+				for _, pts := range j.pending {
+					r.retain(pts)
+				}
+			}
+		}()
+	}
+
+	failed, err := r.writeAll(j.bs)
+	if err == nil {
+		clearAll(j.cleared)
+	}
+	// only the points of the databases whose write failed are sent again
+	for db := range failed {
+		r.retain(j.pending[db])
+	}
+	return err
+}
+
+// start prepares the points and queues them for the writer, unless the queue is full.
+func (r *Reporter) start(p *pipeline, prepare func() (job, error)) {
+	if len(p.jobs) == cap(p.jobs) {
+		if r.overlapPolicy == SkipOverlapping {
+			r.dropped(skippedSends, 1)
+			r.logger.logf(LogWarn, "skipping a send of metrics to InfluxDB, the send queue is full. skipped=%d", r.Status().Skipped)
+			return
+		}
+		p.queued = prepare
+		return
+	}
+
+	j, err := prepare()
+	if err != nil {
+		r.sendFailed(err)
+		return
+	}
+	if len(j.bs) == 0 {
+		clearAll(j.cleared)
+		release(j)
+		return
+	}
+
+	// streamed batches may have filled the queue during prepare
+	r.push(p, j)
+}
+
+// finish records the end of a write and starts the queued send, if any.
+func (r *Reporter) finish(p *pipeline, err error) {
+	r.written(err)
+
+	if p.queued != nil {
+		prepare := p.queued
+		p.queued = nil
+		r.start(p, prepare)
+	}
+}
+
+// drain prepares the last sends, then stops the writer once every queued job is written.
+func (r *Reporter) drain(p *pipeline, last ...func() (job, error)) {
+	for _, prepare := range last {
+		j, err := prepare()
+		if err != nil {
+			r.sendFailed(err)
+			continue
+		}
+		if len(j.bs) == 0 {
+			release(j)
+			continue
+		}
+
+		r.push(p, j)
+	}
+
+	p.close()
+	for err := range p.done {
+		r.written(err)
+	}
+}
+
+// push queues a job, waiting for room in the queue.
+func (r *Reporter) push(p *pipeline, j job) {
+	for {
+		select {
+		case p.jobs <- j:
+			r.recordState()
+			return
+		case err := <-p.done:
+			r.written(err)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package influxdb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestStop(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		ticks     int
+		wantLines []string
+	}{
+		{
+			name:  "no send",
+			ticks: 0,
+		},
+		{
+			name:      "written sends",
+			ticks:     3,
+			wantLines: []string{"requests.count value=1i", "requests.count value=1i", "requests.count value=1i"},
+		},
+		{
+			// the buffered points are flushed by Stop
+			name:      "flush interval",
+			opts:      []Option{WithFlushInterval(time.Hour)},
+			ticks:     2,
+			wantLines: []string{"requests.count value=1i", "requests.count value=1i"},
+		},
+		{
+			name:      "immediate send",
+			opts:      []Option{WithImmediateSend()},
+			ticks:     0,
+			wantLines: []string{"requests.count value=1i"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			influx := newFakeInflux(t)
+			clock := newFakeClock()
+			reg := metrics.NewRegistry()
+			metrics.GetOrRegisterCounter("requests", reg).Inc(1)
+			r := newTestReporter(t, influx.URL, reg, append([]Option{WithClock(clock)}, tt.opts...)...)
+
+			go r.Run()
+			clock.waitTimers(1)
+			for i := 0; i < tt.ticks; i++ {
+				// the tick is received by the loop once Advance returns, so it is handled before Stop
+				clock.Advance(time.Second)
+			}
+			r.Stop()
+
+			if got := influx.lines("db"); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("got the lines %q, want %q", got, tt.wantLines)
+			}
+		})
+	}
+}
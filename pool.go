@@ -0,0 +1,62 @@
+package influxdb
+
+import (
+	"sync"
+)
+
+// The pools recycle the field maps, the point slices and the batches once they are written.
+var (
+	fieldsPool = sync.Pool{New: func() interface{} {
+		return make(map[string]interface{}, 16)
+	}}
+	pointsPool = sync.Pool{New: func() interface{} {
+		pts := make([]Point, 0, 64)
+		return &pts
+	}}
+	batchesPool = sync.Pool{New: func() interface{} {
+		return make(batches)
+	}}
+)
+
+// newFields returns an empty field map.
+func newFields() map[string]interface{} {
+	return fieldsPool.Get().(map[string]interface{})
+}
+
+// putFields empties a field map nothing refers to anymore and recycles it.
+func putFields(fields map[string]interface{}) {
+	for k := range fields {
+		delete(fields, k)
+	}
+	fieldsPool.Put(fields)
+}
+
+// newPoints returns an empty point slice.
+func newPoints() []Point {
+	return (*pointsPool.Get().(*[]Point))[:0]
+}
+
+// newBatches returns empty batches.
+func newBatches() batches {
+	return batchesPool.Get().(batches)
+}
+
+// release recycles the batches of a written job, and the field maps it owns.
+func release(j job) {
+	for _, fields := range j.fields {
+		putFields(fields)
+	}
+
+	for db, pts := range j.bs {
+		for i := range pts {
+			// drop the references to the maps of the points
+			pts[i] = Point{}
+		}
+		pts = pts[:0]
+		pointsPool.Put(&pts)
+		delete(j.bs, db)
+	}
+	if j.bs != nil {
+		batchesPool.Put(j.bs)
+	}
+}
@@ -0,0 +1,36 @@
+package influxdb
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// processStart approximates the start time of the process.
+var processStart = time.Now()
+
+// processStats are the process level stats which depend on the platform.
+type processStats struct {
+	cpuSeconds float64
+	rssBytes   int64
+	openFDs    int64
+}
+
+// ProcessCollector returns a collector maintaining process level gauges in the registry: process.cpu.seconds,
+// process.memory.rss, process.fds.open, process.goroutines and process.uptime.seconds.
+// CPU, memory and file descriptors are only available on Linux.
+func ProcessCollector(reg metrics.Registry) Collector {
+	return func() {
+		metrics.GetOrRegisterGauge("process.goroutines", reg).Update(int64(runtime.NumGoroutine()))
+		metrics.GetOrRegisterGaugeFloat64("process.uptime.seconds", reg).Update(time.Since(processStart).Seconds())
+
+		s, ok := readProcessStats()
+		if !ok {
+			return
+		}
+		metrics.GetOrRegisterGaugeFloat64("process.cpu.seconds", reg).Update(s.cpuSeconds)
+		metrics.GetOrRegisterGauge("process.memory.rss", reg).Update(s.rssBytes)
+		metrics.GetOrRegisterGauge("process.fds.open", reg).Update(s.openFDs)
+	}
+}
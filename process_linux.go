@@ -0,0 +1,42 @@
+package influxdb
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+func readProcessStats() (processStats, bool) {
+	var s processStats
+
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return s, false
+	}
+	s.cpuSeconds = float64(ru.Utime.Sec+ru.Stime.Sec) + float64(ru.Utime.Usec+ru.Stime.Usec)/1e6
+
+	// the second field of statm is the resident set size, in pages
+	statm, err := ioutil.ReadFile("/proc/self/statm")
+	if err != nil {
+		return s, false
+	}
+	fs := strings.Fields(string(statm))
+	if len(fs) < 2 {
+		return s, false
+	}
+	pages, err := strconv.ParseInt(fs[1], 10, 64)
+	if err != nil {
+		return s, false
+	}
+	s.rssBytes = pages * int64(os.Getpagesize())
+
+	fds, err := ioutil.ReadDir("/proc/self/fd")
+	if err != nil {
+		return s, false
+	}
+	s.openFDs = int64(len(fds))
+
+	return s, true
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+// +build !linux
+
+package influxdb
+
+func readProcessStats() (processStats, bool) {
+	return processStats{}, false
+}
@@ -0,0 +1,266 @@
+// Package promwrite provides a sink writing the batches of an InfluxDB reporter to a Prometheus
+// remote write endpoint, like Mimir, VictoriaMetrics or Thanos Receive, so that the same registry
+// feeds both during a migration:
+//
+//	sink, err := promwrite.New("https://mimir/api/v1/push", promwrite.WithHeader("X-Scope-OrgID", "team"))
+//	rep, err := influxdb.New(metrics.DefaultRegistry, 10*time.Second, url, "mydb", "", "", influxdb.WithSinks(sink))
+//
+// Each numeric field of a point becomes a series named after the measurement and the field, like
+// requests_count, or the measurement for the value field, labeled with the tags of the point.
+// Tags whose names collide once sanitized, like a.b and a_b, give a single label.
+// Boolean fields are written as 0 or 1, and string fields are dropped.
+package promwrite
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	uurl "net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	influxdb "github.com/vrischmann/go-metrics-influxdb"
+)
+
+// Sink writes batches of points to a Prometheus remote write endpoint.
+type Sink struct {
+	url      string
+	username string
+	password string
+	headers  map[string]string
+	http     *http.Client
+}
+
+// Option configures a Sink.
+type Option func(*Sink)
+
+// WithBasicAuth authenticates the writes with a username and a password.
+func WithBasicAuth(username, password string) Option {
+	return func(s *Sink) {
+		s.username = username
+		s.password = password
+	}
+}
+
+// WithHeader adds a header to the writes, like X-Scope-OrgID to select the tenant of Mimir
+// or Authorization for a bearer token.
+func WithHeader(key, value string) Option {
+	return func(s *Sink) {
+		s.headers[key] = value
+	}
+}
+
+// WithHTTPClient sets the HTTP client of the writes. The default has a timeout of 10 seconds.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(s *Sink) {
+		s.http = hc
+	}
+}
+
+// New creates a sink writing to the remote write endpoint at url.
+func New(url string, opts ...Option) (*Sink, error) {
+	u, err := uurl.Parse(url)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse remote write url %s. err=%v", url, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid remote write url %s", url)
+	}
+
+	s := &Sink{
+		url:     url,
+		headers: make(map[string]string),
+		http:    &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s, nil
+}
+
+// Write converts the points to series and pushes them to the endpoint.
+func (s *Sink) Write(database string, pts []influxdb.Point) error {
+	series := toSeries(pts, time.Now())
+	if len(series) == 0 {
+		return nil
+	}
+
+	body := snappy.Encode(nil, encodeWriteRequest(series))
+
+	req, err := http.NewRequest("POST", s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+	if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to write metrics to %s. err=%v", s.url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unable to write metrics to %s. status=%s body=%s", s.url, resp.Status, bytes.TrimSpace(msg))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return nil
+}
+
+type label struct {
+	name, value string
+}
+
+type series struct {
+	labels    []label
+	value     float64
+	timestamp int64
+}
+
+// toSeries converts the numeric fields of the points to series, timestamped now if the points aren't.
+func toSeries(pts []influxdb.Point, now time.Time) []series {
+	var res []series
+	for i := range pts {
+		pt := &pts[i]
+
+		ts := pt.Time
+		if ts.IsZero() {
+			ts = now
+		}
+
+		tags := tagLabels(pt.Tags)
+
+		for k, v := range pt.Fields {
+			f, ok := toFloat(v)
+			if !ok {
+				continue
+			}
+
+			name := pt.Measurement
+			if k != "value" {
+				name += "_" + k
+			}
+
+			labels := make([]label, 0, len(tags)+1)
+			labels = append(labels, label{name: "__name__", value: metricName(name)})
+			labels = append(labels, tags...)
+			sort.Slice(labels, func(i, j int) bool { return labels[i].name < labels[j].name })
+
+			res = append(res, series{labels: labels, value: f, timestamp: ts.UnixNano() / int64(time.Millisecond)})
+		}
+	}
+	return res
+}
+
+// tagLabels returns the labels of the tags, sorted by name. Tags whose sanitized names collide, like a.b and a_b,
+// give a single label: the tag already named validly, or else the first one by key. __name__ is left to the series name.
+func tagLabels(tags map[string]string) []label {
+	type candidate struct {
+		label
+		key string
+	}
+	cs := make([]candidate, 0, len(tags))
+	for k, v := range tags {
+		if k == "" || v == "" {
+			continue
+		}
+		name := labelName(k)
+		if name == "__name__" {
+			continue
+		}
+		cs = append(cs, candidate{label: label{name: name, value: v}, key: k})
+	}
+	sort.Slice(cs, func(i, j int) bool {
+		if cs[i].name != cs[j].name {
+			return cs[i].name < cs[j].name
+		}
+		if (cs[i].key == cs[i].name) != (cs[j].key == cs[j].name) {
+			return cs[i].key == cs[i].name
+		}
+		return cs[i].key < cs[j].key
+	})
+
+	res := make([]label, 0, len(cs)+1)
+	for i, c := range cs {
+		if i > 0 && c.name == cs[i-1].name {
+			continue
+		}
+		res = append(res, c.label)
+	}
+	return res
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// metricName replaces the characters Prometheus doesn't allow in a metric name with underscores.
+func metricName(s string) string {
+	return sanitize(s, true)
+}
+
+// labelName replaces the characters Prometheus doesn't allow in a label name with underscores.
+func labelName(s string) string {
+	return sanitize(s, false)
+}
+
+func sanitize(s string, colons bool) string {
+	valid := func(i int, c rune) bool {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+			return true
+		case c == ':':
+			return colons
+		case c >= '0' && c <= '9':
+			return i > 0
+		}
+		return false
+	}
+
+	var b strings.Builder
+	for i, c := range s {
+		if valid(i, c) {
+			b.WriteRune(c)
+			continue
+		}
+		if i == 0 && c >= '0' && c <= '9' {
+			b.WriteByte('_')
+			b.WriteRune(c)
+			continue
+		}
+		b.WriteByte('_')
+	}
+	return b.String()
+}
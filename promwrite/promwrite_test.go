@@ -0,0 +1,61 @@
+package promwrite
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	influxdb "github.com/vrischmann/go-metrics-influxdb"
+)
+
+func TestToSeriesLabels(t *testing.T) {
+	tests := []struct {
+		name string
+		tags map[string]string
+		want []label
+	}{
+		{
+			name: "sorted",
+			tags: map[string]string{"z": "1", "a": "2"},
+			want: []label{{"__name__", "m"}, {"a", "2"}, {"z", "1"}},
+		},
+		{
+			name: "sanitized",
+			tags: map[string]string{"a.b": "1", "0c": "2"},
+			want: []label{{"_0c", "2"}, {"__name__", "m"}, {"a_b", "1"}},
+		},
+		{
+			name: "collision with a valid name",
+			tags: map[string]string{"a.b": "1", "a_b": "2", "a-b": "3"},
+			want: []label{{"__name__", "m"}, {"a_b", "2"}},
+		},
+		{
+			name: "collision",
+			tags: map[string]string{"a.b": "1", "a-b": "2"},
+			want: []label{{"__name__", "m"}, {"a_b", "2"}},
+		},
+		{
+			name: "name",
+			tags: map[string]string{"__name__": "x", "host": "h"},
+			want: []label{{"__name__", "m"}, {"host", "h"}},
+		},
+		{
+			name: "empty",
+			tags: map[string]string{"": "x", "host": ""},
+			want: []label{{"__name__", "m"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pts := []influxdb.Point{{Measurement: "m", Tags: tt.tags, Fields: map[string]interface{}{"value": 1.0}}}
+			ss := toSeries(pts, time.Now())
+			if len(ss) != 1 {
+				t.Fatalf("got %d series, want 1", len(ss))
+			}
+			if !reflect.DeepEqual(ss[0].labels, tt.want) {
+				t.Errorf("got the labels %v, want %v", ss[0].labels, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package promwrite
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// The remote write protocol is small enough to encode by hand, which spares the dependencies of the generated code:
+//
+//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//	message Label { string name = 1; string value = 2; }
+//	message Sample { double value = 1; int64 timestamp = 2; }
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+func encodeWriteRequest(ss []series) []byte {
+	var buf, ts, msg []byte
+	for _, s := range ss {
+		ts = ts[:0]
+		for _, l := range s.labels {
+			msg = msg[:0]
+			msg = appendString(msg, 1, l.name)
+			msg = appendString(msg, 2, l.value)
+			ts = appendBytes(ts, 1, msg)
+		}
+
+		msg = msg[:0]
+		msg = appendKey(msg, 1, wireFixed64)
+		msg = binary.LittleEndian.AppendUint64(msg, math.Float64bits(s.value))
+		msg = appendKey(msg, 2, wireVarint)
+		msg = binary.AppendUvarint(msg, uint64(s.timestamp))
+		ts = appendBytes(ts, 2, msg)
+
+		buf = appendBytes(buf, 1, ts)
+	}
+	return buf
+}
+
+func appendKey(b []byte, field int, wire int) []byte {
+	return binary.AppendUvarint(b, uint64(field)<<3|uint64(wire))
+}
+
+func appendBytes(b []byte, field int, v []byte) []byte {
+	b = appendKey(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendString(b []byte, field int, v string) []byte {
+	b = appendKey(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
@@ -0,0 +1,36 @@
+package influxdb
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// panicked logs a panic recovered while reading a metric, with its stack, so that a faulty metric,
+// like a functional gauge, doesn't kill the process and the other metrics are still reported.
+func (r *Reporter) panicked(v interface{}, name string) {
+	r.logger.logf(LogError, "recovered from a panic while reading metric %s, skipping it. err=%v\n%s", name, v, debug.Stack())
+}
+
+// safeEntryPoints returns the points of an entry, or none if reading it panics.
+func (r *Reporter) safeEntryPoints(host string, e entry, arena []Point, now time.Time) (b built, res []Point) {
+	n := len(arena)
+	defer func() {
+		if v := recover(); v != nil {
+			r.panicked(v, e.name)
+			b, res = built{}, arena[:n]
+		}
+	}()
+
+	return r.entryPoints(host, e, arena, now)
+}
+
+// safeCollect runs a collector, recovering from its panics.
+func (r *Reporter) safeCollect(c Collector) {
+	defer func() {
+		if v := recover(); v != nil {
+			r.panicked(v, "collector")
+		}
+	}()
+
+	c()
+}
@@ -0,0 +1,61 @@
+package influxdb
+
+import (
+	"github.com/rcrowley/go-metrics"
+)
+
+// Source is a registry attached to a reporter.
+type Source struct {
+	Registry metrics.Registry
+	// Prefix is prepended to the names of the metrics of the registry.
+	Prefix string
+	// Tags are added to all the points of the registry.
+	Tags map[string]string
+	// Database is the database the points of the registry are written to, instead of the database of the reporter.
+	Database string
+}
+
+// each calls f for each entry of each registry, with the name prefixed by the prefix of its registry.
+func (r *Reporter) each(f func(src *Source, name string, i interface{})) {
+	for _, src := range r.sources {
+		src.Registry.Each(func(name string, i interface{}) {
+			f(src, src.Prefix+name, i)
+		})
+	}
+}
+
+// mergeTags returns a new map with the tags of a and b, b winning.
+func mergeTags(a, b map[string]string) map[string]string {
+	res := make(map[string]string, len(a)+len(b))
+	for k, v := range a {
+		res[k] = v
+	}
+	for k, v := range b {
+		res[k] = v
+	}
+	return res
+}
+
+// addTags adds tags to points which don't have them already.
+// Points without tags share the map, so the tags of a point must never be modified.
+func addTags(pts []Point, tags map[string]string) {
+	if len(tags) == 0 {
+		return
+	}
+
+	for i := range pts {
+		if len(pts[i].Tags) == 0 {
+			pts[i].Tags = tags
+			continue
+		}
+
+		res := make(map[string]string, len(pts[i].Tags)+len(tags))
+		for k, v := range tags {
+			res[k] = v
+		}
+		for k, v := range pts[i].Tags {
+			res[k] = v
+		}
+		pts[i].Tags = res
+	}
+}
@@ -0,0 +1,77 @@
+package influxdb
+
+import (
+	"reflect"
+	"time"
+)
+
+// resettingTimer is implemented by the ResettingTimer of some go-metrics forks, like the one of go-ethereum.
+// Its Snapshot method resets it, and returns a fork specific type, so it is called by reflection.
+type resettingTimer interface {
+	Values() []int64
+	Mean() float64
+	UpdateSince(time.Time)
+}
+
+// resettingSnapshot is implemented by the snapshots of resetting timers, whose percentiles are between 0 and 100.
+type resettingSnapshot interface {
+	Values() []int64
+	Mean() float64
+	Percentiles([]float64) []int64
+}
+
+// snapshotResetting takes a snapshot of a resetting timer, which resets it.
+func snapshotResetting(t resettingTimer) (resettingSnapshot, bool) {
+	m := reflect.ValueOf(t).MethodByName("Snapshot")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return nil, false
+	}
+
+	s, ok := m.Call(nil)[0].Interface().(resettingSnapshot)
+	return s, ok
+}
+
+// resettingFields returns the fields of the values recorded by a resetting timer since the last send.
+func (r *Reporter) resettingFields(t resettingTimer) (string, map[string]interface{}, []float64) {
+	s, ok := snapshotResetting(t)
+	if !ok {
+		return "", nil, nil
+	}
+
+	values := s.Values()
+	if len(values) == 0 {
+		// nothing happened during the interval
+		return "", nil, nil
+	}
+
+	ps100 := make([]float64, len(r.percentiles))
+	for i, p := range r.percentiles {
+		ps100[i] = p * 100
+	}
+	pvs := s.Percentiles(ps100)
+
+	min, max := values[0], values[0]
+	for _, v := range values {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+
+	unit := float64(r.durationUnit.Nanoseconds())
+	fields := map[string]interface{}{
+		"count": int64(len(values)),
+		"max":   float64(max) / unit,
+		"mean":  s.Mean() / unit,
+		"min":   float64(min) / unit,
+	}
+
+	ps := make([]float64, len(pvs))
+	for i, v := range pvs {
+		ps[i] = float64(v) / unit
+	}
+
+	return TypeTimer, fields, ps
+}
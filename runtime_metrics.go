@@ -0,0 +1,82 @@
+//go:build go1.16
+// +build go1.16
+
+package influxdb
+
+import (
+	"math"
+	rtmetrics "runtime/metrics"
+	"strings"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+var runtimeQuantiles = []struct {
+	q    float64
+	name string
+}{
+	{0.5, "p50"},
+	{0.9, "p90"},
+	{0.99, "p99"},
+}
+
+// runtimeMetricName turns a runtime/metrics name, like /gc/heap/allocs:bytes, into runtime.gc.heap.allocs.bytes.
+func runtimeMetricName(name string) string {
+	return "runtime" + strings.NewReplacer("/", ".", ":", ".", "-", "_").Replace(name)
+}
+
+// histogramQuantile returns the upper bound of the bucket holding the quantile q,
+// or its lower bound for the last, unbounded, bucket.
+func histogramQuantile(h *rtmetrics.Float64Histogram, q float64) float64 {
+	var total uint64
+	for _, c := range h.Counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(q * float64(total)))
+	var n uint64
+	for i, c := range h.Counts {
+		n += c
+		if n >= rank {
+			if math.IsInf(h.Buckets[i+1], 1) {
+				return h.Buckets[i]
+			}
+			return h.Buckets[i+1]
+		}
+	}
+	return h.Buckets[len(h.Buckets)-1]
+}
+
+// RuntimeMetricsCollector returns a collector sampling every metric of the runtime/metrics package into gauges
+// of the registry: the scheduler latencies, the GC pauses, the heap classes and more.
+// Histograms are written as their p50, p90 and p99.
+func RuntimeMetricsCollector(reg metrics.Registry) Collector {
+	descs := rtmetrics.All()
+	samples := make([]rtmetrics.Sample, len(descs))
+	for i, d := range descs {
+		samples[i].Name = d.Name
+	}
+
+	return func() {
+		rtmetrics.Read(samples)
+
+		for _, s := range samples {
+			name := runtimeMetricName(s.Name)
+
+			switch s.Value.Kind() {
+			case rtmetrics.KindUint64:
+				metrics.GetOrRegisterGauge(name, reg).Update(int64(s.Value.Uint64()))
+			case rtmetrics.KindFloat64:
+				metrics.GetOrRegisterGaugeFloat64(name, reg).Update(s.Value.Float64())
+			case rtmetrics.KindFloat64Histogram:
+				h := s.Value.Float64Histogram()
+				for _, q := range runtimeQuantiles {
+					metrics.GetOrRegisterGaugeFloat64(name+"."+q.name, reg).Update(histogramQuantile(h, q.q))
+				}
+			}
+		}
+	}
+}
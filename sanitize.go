@@ -0,0 +1,65 @@
+package influxdb
+
+import (
+	"strings"
+
+	"github.com/influxdata/influxdb/client"
+)
+
+// Sanitizer rewrites a measurement, field key, tag key or tag value before it is written.
+type Sanitizer func(s string) string
+
+var defaultReplacer = strings.NewReplacer(
+	" ", "_",
+	",", "_",
+	"=", "_",
+	"\"", "_",
+	"\n", "_",
+	"\r", "_",
+	"\t", "_",
+)
+
+// DefaultSanitizer replaces the characters which have a special meaning in the line protocol
+// (spaces, commas, equal signs, double quotes and line breaks) with underscores.
+func DefaultSanitizer(s string) string {
+	return defaultReplacer.Replace(s)
+}
+
+// sanitizePoint sanitizes a point, copying its fields and tags only if a key or value changes.
+func sanitizePoint(pt *client.Point, s Sanitizer) {
+	pt.Measurement = s(pt.Measurement)
+
+	if fieldsChanged(pt.Fields, s) {
+		fields := make(map[string]interface{}, len(pt.Fields))
+		for k, v := range pt.Fields {
+			fields[s(k)] = v
+		}
+		pt.Fields = fields
+	}
+
+	if tagsChanged(pt.Tags, s) {
+		tags := make(map[string]string, len(pt.Tags))
+		for k, v := range pt.Tags {
+			tags[s(k)] = s(v)
+		}
+		pt.Tags = tags
+	}
+}
+
+func fieldsChanged(fields map[string]interface{}, s Sanitizer) bool {
+	for k := range fields {
+		if s(k) != k {
+			return true
+		}
+	}
+	return false
+}
+
+func tagsChanged(tags map[string]string, s Sanitizer) bool {
+	for k, v := range tags {
+		if s(k) != k || s(v) != v {
+			return true
+		}
+	}
+	return false
+}
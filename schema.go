@@ -0,0 +1,229 @@
+package influxdb
+
+import (
+	"math"
+
+	"github.com/influxdata/influxdb/client"
+)
+
+// SchemaMode tells the reporter what to do when a field changes type between two sends.
+type SchemaMode int
+
+const (
+	// SchemaOff doesn't check field types.
+	SchemaOff SchemaMode = iota
+	// SchemaWarn logs a warning the first time a field changes type, and writes it anyway.
+	SchemaWarn
+	// SchemaCoerce converts the field to the type it was first written with, or drops it when
+	// that's not possible, and logs a warning the first time it happens.
+	SchemaCoerce
+)
+
+type fieldType int
+
+const (
+	fieldInteger fieldType = iota + 1
+	fieldUnsigned
+	fieldFloat
+	fieldBoolean
+	fieldString
+)
+
+func (t fieldType) String() string {
+	switch t {
+	case fieldInteger:
+		return "integer"
+	case fieldUnsigned:
+		return "unsigned"
+	case fieldFloat:
+		return "float"
+	case fieldBoolean:
+		return "boolean"
+	case fieldString:
+		return "string"
+	}
+	return "unknown"
+}
+
+func typeOf(v interface{}) fieldType {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32:
+		return fieldInteger
+	case uint64:
+		// the only unsigned type written with the u suffix
+		return fieldUnsigned
+	case float32, float64:
+		return fieldFloat
+	case bool:
+		return fieldBoolean
+	case string:
+		return fieldString
+	}
+	return 0
+}
+
+// maxSchemaFields is the number of fields the schema remembers. Past it, the schema starts over,
+// so that measurements or fields named after unbounded values don't grow it forever.
+const maxSchemaFields = 10000
+
+// schema remembers the type each field of each measurement was first written with.
+type schema struct {
+	mode   SchemaMode
+	types  map[string]map[string]fieldType
+	fields int
+	warned map[string]bool
+	logger leveledLogger
+}
+
+func newSchema(mode SchemaMode) *schema {
+	return &schema{
+		mode:   mode,
+		types:  make(map[string]map[string]fieldType),
+		warned: make(map[string]bool),
+		logger: newLeveledLogger(),
+	}
+}
+
+// reset forgets the known types and the warnings.
+func (s *schema) reset() {
+	s.logger.logf(LogDebug, "the schema check knows more than %d fields, starting over", maxSchemaFields)
+	s.types = make(map[string]map[string]fieldType)
+	s.fields = 0
+	s.warned = make(map[string]bool)
+}
+
+// check applies the mode to the points and returns the points to keep. The slice is filtered in place,
+// but the fields of a point are copied before they are coerced, since they may be shared with the caller.
+func (s *schema) check(pts []client.Point) []client.Point {
+	res := pts[:0]
+
+	for _, pt := range pts {
+		if s.fields+len(pt.Fields) > maxSchemaFields {
+			s.reset()
+		}
+
+		known, ok := s.types[pt.Measurement]
+		if !ok {
+			known = make(map[string]fieldType, len(pt.Fields))
+			s.types[pt.Measurement] = known
+		}
+
+		copied := false
+		for k, v := range pt.Fields {
+			t := typeOf(v)
+
+			first, ok := known[k]
+			if !ok {
+				known[k] = t
+				s.fields++
+				continue
+			}
+			if t == first {
+				continue
+			}
+
+			s.warn(pt.Measurement, k, first, t)
+
+			if s.mode != SchemaCoerce {
+				continue
+			}
+
+			if !copied {
+				fields := make(map[string]interface{}, len(pt.Fields))
+				for k, v := range pt.Fields {
+					fields[k] = v
+				}
+				pt.Fields = fields
+				copied = true
+			}
+
+			if c, ok := coerce(v, first); ok {
+				pt.Fields[k] = c
+			} else {
+				delete(pt.Fields, k)
+			}
+		}
+
+		if len(pt.Fields) > 0 {
+			res = append(res, pt)
+		}
+	}
+
+	return res
+}
+
+func (s *schema) warn(measurement, field string, first, t fieldType) {
+	key := measurement + "\x00" + field + "\x00" + t.String()
+	if s.warned[key] {
+		return
+	}
+	s.warned[key] = true
+
+	s.logger.logf(LogWarn, "field %s of measurement %s changed type from %s to %s, InfluxDB will reject it", field, measurement, first, t)
+}
+
+func coerce(v interface{}, t fieldType) (interface{}, bool) {
+	switch t {
+	case fieldFloat:
+		switch n := v.(type) {
+		case uint64:
+			return float64(n), true
+		case float32:
+			return float64(n), true
+		}
+		if n, ok := asInt64(v); ok {
+			return float64(n), true
+		}
+	case fieldInteger:
+		switch n := v.(type) {
+		case uint64:
+			if n <= math.MaxInt64 {
+				return int64(n), true
+			}
+		case float32:
+			return int64(n), true
+		case float64:
+			return int64(n), true
+		}
+	case fieldUnsigned:
+		switch n := v.(type) {
+		case float32:
+			if n >= 0 {
+				return uint64(n), true
+			}
+		case float64:
+			if n >= 0 {
+				return uint64(n), true
+			}
+		}
+		if n, ok := asInt64(v); ok && n >= 0 {
+			return uint64(n), true
+		}
+	}
+	return nil, false
+}
+
+// asInt64 converts the values of the types written as integers.
+func asInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint:
+		return int64(n), true
+	case uint8:
+		return int64(n), true
+	case uint16:
+		return int64(n), true
+	case uint32:
+		return int64(n), true
+	}
+	return 0, false
+}
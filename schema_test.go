@@ -0,0 +1,109 @@
+package influxdb
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestSchemaCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     SchemaMode
+		first    map[string]interface{}
+		then     map[string]interface{}
+		want     map[string]interface{}
+		warnings int
+	}{
+		{
+			name:     "same types",
+			mode:     SchemaCoerce,
+			first:    map[string]interface{}{"value": int64(1), "rate": 1.5},
+			then:     map[string]interface{}{"value": int64(2), "rate": 2.5},
+			want:     map[string]interface{}{"value": int64(2), "rate": 2.5},
+			warnings: 0,
+		},
+		{
+			name:     "warn",
+			mode:     SchemaWarn,
+			first:    map[string]interface{}{"value": int64(1)},
+			then:     map[string]interface{}{"value": 2.5},
+			want:     map[string]interface{}{"value": 2.5},
+			warnings: 1,
+		},
+		{
+			name:     "coerce",
+			mode:     SchemaCoerce,
+			first:    map[string]interface{}{"value": int64(1), "rate": 1.5, "name": "a"},
+			then:     map[string]interface{}{"value": 2.5, "rate": int64(2), "name": int64(3)},
+			want:     map[string]interface{}{"value": int64(2), "rate": float64(2)},
+			warnings: 3,
+		},
+		{
+			// uint64 is written with the u suffix, which InfluxDB doesn't take for an integer field
+			name:     "unsigned",
+			mode:     SchemaCoerce,
+			first:    map[string]interface{}{"value": uint64(1), "count": int64(1)},
+			then:     map[string]interface{}{"value": int64(2), "count": uint64(3)},
+			want:     map[string]interface{}{"value": uint64(2), "count": int64(3)},
+			warnings: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSchema(tt.mode)
+			log := &recordingLogger{}
+			s.logger.Logger = log
+
+			s.check([]Point{{Measurement: "m", Fields: tt.first}})
+			then := make(map[string]interface{}, len(tt.then))
+			for k, v := range tt.then {
+				then[k] = v
+			}
+			pts := s.check([]Point{{Measurement: "m", Fields: then}})
+			if len(pts) != 1 || !reflect.DeepEqual(pts[0].Fields, tt.want) {
+				t.Errorf("got the points %v, want the fields %v", pts, tt.want)
+			}
+			if !reflect.DeepEqual(then, tt.then) {
+				t.Errorf("the fields of the caller were changed to %v", then)
+			}
+
+			// a warning is only logged the first time
+			s.check([]Point{{Measurement: "m", Fields: tt.then}})
+			if len(log.msgs) != tt.warnings {
+				t.Errorf("logged %q, want %d warnings", log.msgs, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestSchemaCheckDropsPoint(t *testing.T) {
+	s := newSchema(SchemaCoerce)
+	s.logger.Logger = NopLogger
+
+	s.check([]Point{{Measurement: "m", Fields: map[string]interface{}{"name": "a"}}})
+	if pts := s.check([]Point{{Measurement: "m", Fields: map[string]interface{}{"name": true}}}); len(pts) != 0 {
+		t.Errorf("got the points %v, want the point without fields dropped", pts)
+	}
+}
+
+func TestSchemaCheckBound(t *testing.T) {
+	s := newSchema(SchemaCoerce)
+	s.logger.Logger = NopLogger
+
+	s.check([]Point{{Measurement: "m", Fields: map[string]interface{}{"value": int64(1)}}})
+	for i := 0; s.fields < maxSchemaFields; i++ {
+		s.check([]Point{{Measurement: "other", Fields: map[string]interface{}{"f" + strconv.Itoa(i): int64(1)}}})
+	}
+
+	// once full, the schema starts over and takes the new type
+	s.check([]Point{{Measurement: "new", Fields: map[string]interface{}{"value": int64(1)}}})
+	if len(s.types) != 1 || s.fields != 1 {
+		t.Fatalf("the schema knows %d measurements and %d fields, want it to start over", len(s.types), s.fields)
+	}
+	pts := s.check([]Point{{Measurement: "m", Fields: map[string]interface{}{"value": 2.5}}})
+	if got := pts[0].Fields["value"]; got != 2.5 {
+		t.Errorf("value is %v, want the type of before the reset forgotten", got)
+	}
+}
@@ -0,0 +1,84 @@
+package influxdb
+
+import (
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// selfMetrics are the metrics of the reporter itself, registered into its registry.
+// They are updated as the points are written and reported by the next send, so reporting them
+// never triggers more writes.
+type selfMetrics struct {
+	points   metrics.Counter
+	bytes    metrics.Counter
+	duration metrics.Timer
+	failures metrics.Gauge
+	queued   metrics.Gauge
+	buffered metrics.Gauge
+	dropped  [dropKinds]metrics.Counter
+	restarts metrics.Counter
+	stalls   metrics.Counter
+	added    metrics.Counter
+	removed  metrics.Counter
+}
+
+func newSelfMetrics(reg metrics.Registry, prefix string) *selfMetrics {
+	s := &selfMetrics{
+		points:   metrics.GetOrRegisterCounter(prefix+".points_sent", reg),
+		bytes:    metrics.GetOrRegisterCounter(prefix+".bytes_written", reg),
+		duration: metrics.GetOrRegisterTimer(prefix+".write_duration", reg),
+		failures: metrics.GetOrRegisterGauge(prefix+".consecutive_failures", reg),
+		queued:   metrics.GetOrRegisterGauge(prefix+".queued_sends", reg),
+		buffered: metrics.GetOrRegisterGauge(prefix+".buffered_points", reg),
+		restarts: metrics.GetOrRegisterCounter(prefix+".loop_restarts", reg),
+		stalls:   metrics.GetOrRegisterCounter(prefix+".loop_stalls", reg),
+		added:    metrics.GetOrRegisterCounter(prefix+".metrics_added", reg),
+		removed:  metrics.GetOrRegisterCounter(prefix+".metrics_removed", reg),
+	}
+	for kind, name := range dropNames {
+		s.dropped[kind] = metrics.GetOrRegisterCounter(prefix+"."+name, reg)
+	}
+	return s
+}
+
+// wrote records a successful write.
+func (s *selfMetrics) wrote(points, bytes int, d time.Duration) {
+	if s == nil {
+		return
+	}
+	s.points.Inc(int64(points))
+	s.bytes.Inc(int64(bytes))
+	s.duration.Update(d)
+}
+
+// recordState records the failures and the depth of the queue and of the buffer, in the status
+// and the self-metrics.
+func (r *Reporter) recordState() {
+	queued := 0
+	if r.pipe != nil {
+		queued = len(r.pipe.jobs)
+	}
+	buffered := 0
+	for _, pts := range r.buffer.bs {
+		buffered += len(pts)
+	}
+
+	r.status.state(r.failures, queued, buffered)
+	if r.self != nil {
+		r.self.failures.Update(int64(r.failures))
+		r.self.queued.Update(int64(queued))
+		r.self.buffered.Update(int64(buffered))
+	}
+}
+
+// dropped counts n sends, metrics or points dropped by the reporter, in the status and the self-metrics.
+func (r *Reporter) dropped(kind dropKind, n int64) {
+	if n == 0 {
+		return
+	}
+	r.status.dropped(kind, n)
+	if r.self != nil {
+		r.self.dropped[kind].Inc(n)
+	}
+}
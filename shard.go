@@ -0,0 +1,96 @@
+package influxdb
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// shardReplicas is the number of points of each shard on the hash ring, to spread the series evenly.
+const shardReplicas = 128
+
+// shards spreads the series across InfluxDB instances by consistent hashing, so that adding or removing
+// an instance only moves the series of its neighbours on the ring.
+type shards struct {
+	clients []*Client
+	by      string
+	hashes  []uint32
+	owners  []int
+}
+
+func newShards(clients []*Client, by string) (*shards, error) {
+	if len(clients) == 0 {
+		return nil, fmt.Errorf("no InfluxDB shard")
+	}
+
+	s := &shards{clients: clients, by: by}
+	type node struct {
+		hash  uint32
+		owner int
+	}
+	nodes := make([]node, 0, len(clients)*shardReplicas)
+	for i, c := range clients {
+		for v := 0; v < shardReplicas; v++ {
+			nodes = append(nodes, node{hash: hash32(c.config.URL.String() + "#" + strconv.Itoa(v)), owner: i})
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].hash < nodes[j].hash })
+
+	s.hashes = make([]uint32, len(nodes))
+	s.owners = make([]int, len(nodes))
+	for i, n := range nodes {
+		s.hashes[i], s.owners[i] = n.hash, n.owner
+	}
+	return s, nil
+}
+
+// hash32 hashes with FNV-1a, mixed with the finalizer of MurmurHash3: FNV alone spreads strings
+// differing only by their last characters, like the replicas of a shard, unevenly on the ring.
+func hash32(s string) uint32 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return uint32(x)
+}
+
+// shard returns the index of the client of a point: the first on the ring after the hash of its tag,
+// or of its measurement if the shards aren't chosen by tag or the point doesn't have it.
+func (s *shards) shard(pt *Point) int {
+	key, ok := pt.Tags[s.by]
+	if s.by == "" || !ok {
+		key = pt.Measurement
+	}
+
+	h := hash32(key)
+	i := sort.Search(len(s.hashes), func(i int) bool { return s.hashes[i] >= h })
+	if i == len(s.hashes) {
+		i = 0
+	}
+	return s.owners[i]
+}
+
+// writeShards writes the points of a batch to their shards, and returns the first error.
+func (r *Reporter) writeShards(database string, pts []Point) error {
+	groups := make([][]Point, len(r.shards.clients))
+	for i := range pts {
+		s := r.shards.shard(&pts[i])
+		groups[s] = append(groups[s], pts[i])
+	}
+
+	var res error
+	for s, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		if err := r.writeTo(r.shards.clients[s], database, group); err != nil && res == nil {
+			res = err
+		}
+	}
+	return res
+}
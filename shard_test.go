@@ -0,0 +1,83 @@
+package influxdb
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestShards(t *testing.T) {
+	var clients []*Client
+	for i := 0; i < 4; i++ {
+		clients = append(clients, newTestClient(t, fmt.Sprintf("http://shard%d:8086", i)))
+	}
+	three, err := newShards(clients[:3], "host")
+	if err != nil {
+		t.Fatalf("unable to create shards. err=%v", err)
+	}
+	four, err := newShards(clients, "host")
+	if err != nil {
+		t.Fatalf("unable to create shards. err=%v", err)
+	}
+
+	const hosts = 1000
+	counts := make([]int, 3)
+	for i := 0; i < hosts; i++ {
+		pt := Point{Measurement: "m", Tags: map[string]string{"host": fmt.Sprintf("host%d", i)}}
+		before := three.shard(&pt)
+		counts[before]++
+
+		// a new shard only takes series from the others
+		if after := four.shard(&pt); after != before && after != 3 {
+			t.Errorf("host%d moved from shard %d to shard %d, want it to stay or move to the new one", i, before, after)
+		}
+	}
+	for i, n := range counts {
+		if n < hosts/5 || n > hosts/2 {
+			t.Errorf("shard %d has %d of the %d hosts, want them spread", i, n, hosts)
+		}
+	}
+
+	// without the tag, the points are sharded by measurement
+	pt := Point{Measurement: "requests"}
+	if got, want := three.shard(&pt), three.shard(&Point{Measurement: "requests", Tags: map[string]string{"region": "eu"}}); got != want {
+		t.Errorf("got the shards %d and %d for the same measurement, want the same", got, want)
+	}
+}
+
+func TestWithShards(t *testing.T) {
+	influxes := []*fakeInflux{newFakeInflux(t), newFakeInflux(t)}
+	var clients []*Client
+	for _, influx := range influxes {
+		clients = append(clients, newTestClient(t, influx.URL))
+	}
+	r := newTestReporter(t, unreachable, metrics.NewRegistry(), WithShards("host", clients...))
+
+	var pts []Point
+	for i := 0; i < 20; i++ {
+		pts = append(pts, Point{Measurement: "deploy", Tags: map[string]string{"host": fmt.Sprintf("host%d", i)}, Fields: map[string]interface{}{"value": int64(1)}})
+	}
+	r.WritePoints(pts...)
+	if err := r.Send(); err != nil {
+		t.Fatalf("unable to send. err=%v", err)
+	}
+
+	// each point is written once, to its shard
+	seen := make(map[string]int)
+	for s, influx := range influxes {
+		for _, line := range influx.lines("db") {
+			host := strings.TrimPrefix(strings.Fields(line)[0], "deploy,host=")
+			if want := r.shards.shard(&Point{Tags: map[string]string{"host": host}}); want != s {
+				t.Errorf("%s written to shard %d, want %d", host, s, want)
+			}
+			seen[host]++
+		}
+	}
+	for _, pt := range pts {
+		if n := seen[pt.Tags["host"]]; n != 1 {
+			t.Errorf("%s written %d times, want once", pt.Tags["host"], n)
+		}
+	}
+}
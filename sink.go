@@ -0,0 +1,17 @@
+package influxdb
+
+// Sink receives a copy of every batch of points, to feed another backend, like a Prometheus compatible TSDB,
+// from the same registry. Write runs in the writer goroutine before the batch is written to InfluxDB,
+// and must not keep the points once it returns, since they are reused by the next send.
+type Sink interface {
+	Write(database string, pts []Point) error
+}
+
+// writeSinks gives a batch to every sink. A failing sink is logged and never fails the write to InfluxDB.
+func (r *Reporter) writeSinks(database string, pts []Point) {
+	for _, s := range r.sinks {
+		if err := s.Write(database, pts); err != nil {
+			r.logger.logf(LogWarn, "unable to write metrics to a sink. err=%v", err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package influxdb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+type timedValue struct {
+	t time.Time
+	v int64
+}
+
+// SlidingWindowSample is a sample which only keeps the values recorded during the last window,
+// so that percentiles reflect recent behavior rather than an exponentially decayed history.
+// It keeps at most reservoirSize values, dropping the oldest ones first.
+type SlidingWindowSample struct {
+	mu            sync.Mutex
+	window        time.Duration
+	reservoirSize int
+	count         int64
+	// values is a ring of the n values in the window, the oldest at head
+	values []timedValue
+	head   int
+	n      int
+	clock  Clock
+}
+
+// NewSlidingWindowSample constructs a new sliding window sample.
+func NewSlidingWindowSample(window time.Duration, reservoirSize int) *SlidingWindowSample {
+	return NewSlidingWindowSampleWithClock(window, reservoirSize, realClock{})
+}
+
+// NewSlidingWindowSampleWithClock constructs a new sliding window sample whose values expire on the clock, for tests.
+func NewSlidingWindowSampleWithClock(window time.Duration, reservoirSize int, clock Clock) *SlidingWindowSample {
+	return &SlidingWindowSample{
+		window:        window,
+		reservoirSize: reservoirSize,
+		values:        make([]timedValue, reservoirSize),
+		clock:         clock,
+	}
+}
+
+// NewSlidingWindowTimer constructs a timer backed by a sliding window sample.
+func NewSlidingWindowTimer(window time.Duration, reservoirSize int) metrics.Timer {
+	return NewSlidingWindowTimerWithClock(window, reservoirSize, realClock{})
+}
+
+// NewSlidingWindowTimerWithClock constructs a timer backed by a sliding window sample on the clock, for tests.
+func NewSlidingWindowTimerWithClock(window time.Duration, reservoirSize int, clock Clock) metrics.Timer {
+	s := NewSlidingWindowSampleWithClock(window, reservoirSize, clock)
+	return &sampledTimer{
+		Timer:  metrics.NewCustomTimer(metrics.NewHistogram(s), metrics.NewMeter()),
+		sample: s,
+	}
+}
+
+// GetOrRegisterSlidingWindowTimer returns an existing timer or constructs and registers a new sliding window timer.
+func GetOrRegisterSlidingWindowTimer(name string, r metrics.Registry, window time.Duration, reservoirSize int) metrics.Timer {
+	if r == nil {
+		r = metrics.DefaultRegistry
+	}
+	return r.GetOrRegister(name, func() metrics.Timer {
+		return NewSlidingWindowTimer(window, reservoirSize)
+	}).(metrics.Timer)
+}
+
+// at returns the i-th oldest value. It must be called with the lock held.
+func (s *SlidingWindowSample) at(i int) timedValue {
+	return s.values[(s.head+i)%len(s.values)]
+}
+
+// push adds a value, dropping the oldest one when the reservoir is full. It must be called with the lock held.
+func (s *SlidingWindowSample) push(tv timedValue) {
+	if s.n == len(s.values) {
+		if s.reservoirSize > 0 {
+			s.values[s.head] = tv
+			s.head = (s.head + 1) % len(s.values)
+			return
+		}
+
+		// without a reservoir size, the ring grows
+		values := make([]timedValue, 2*len(s.values)+16)
+		for i := 0; i < s.n; i++ {
+			values[i] = s.at(i)
+		}
+		s.values, s.head = values, 0
+	}
+
+	s.values[(s.head+s.n)%len(s.values)] = tv
+	s.n++
+}
+
+// trim drops the values older than the window. It must be called with the lock held.
+func (s *SlidingWindowSample) trim(now time.Time) {
+	cutoff := now.Add(-s.window)
+
+	for s.n > 0 && s.values[s.head].t.Before(cutoff) {
+		s.head = (s.head + 1) % len(s.values)
+		s.n--
+	}
+}
+
+// Clear clears all samples.
+func (s *SlidingWindowSample) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.count = 0
+	s.head, s.n = 0, 0
+}
+
+// Count returns the number of samples recorded, which may exceed the number of values in the window.
+func (s *SlidingWindowSample) Count() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
+
+// Max returns the maximum value in the window.
+func (s *SlidingWindowSample) Max() int64 {
+	return metrics.SampleMax(s.Values())
+}
+
+// Mean returns the mean of the values in the window.
+func (s *SlidingWindowSample) Mean() float64 {
+	return metrics.SampleMean(s.Values())
+}
+
+// Min returns the minimum value in the window.
+func (s *SlidingWindowSample) Min() int64 {
+	return metrics.SampleMin(s.Values())
+}
+
+// Percentile returns an arbitrary percentile of the values in the window.
+func (s *SlidingWindowSample) Percentile(p float64) float64 {
+	return metrics.SamplePercentile(s.Values(), p)
+}
+
+// Percentiles returns a slice of arbitrary percentiles of the values in the window.
+func (s *SlidingWindowSample) Percentiles(ps []float64) []float64 {
+	return metrics.SamplePercentiles(s.Values(), ps)
+}
+
+// Size returns the number of values in the window.
+func (s *SlidingWindowSample) Size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trim(s.clock.Now())
+	return s.n
+}
+
+// Snapshot returns a read-only copy of the sample.
+func (s *SlidingWindowSample) Snapshot() metrics.Sample {
+	s.mu.Lock()
+	count := s.count
+	s.mu.Unlock()
+	return metrics.NewSampleSnapshot(count, s.Values())
+}
+
+// StdDev returns the standard deviation of the values in the window.
+func (s *SlidingWindowSample) StdDev() float64 {
+	return metrics.SampleStdDev(s.Values())
+}
+
+// Sum returns the sum of the values in the window.
+func (s *SlidingWindowSample) Sum() int64 {
+	return metrics.SampleSum(s.Values())
+}
+
+// Update samples a new value.
+func (s *SlidingWindowSample) Update(v int64) {
+	now := s.clock.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.count++
+	s.trim(now)
+	s.push(timedValue{t: now, v: v})
+}
+
+// Values returns a copy of the values in the window.
+func (s *SlidingWindowSample) Values() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.trim(s.clock.Now())
+	values := make([]int64, s.n)
+	for i := range values {
+		values[i] = s.at(i).v
+	}
+	return values
+}
+
+// Variance returns the variance of the values in the window.
+func (s *SlidingWindowSample) Variance() float64 {
+	return metrics.SampleVariance(s.Values())
+}
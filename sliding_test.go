@@ -0,0 +1,104 @@
+package influxdb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSlidingWindowSample(t *testing.T) {
+	type update struct {
+		after  time.Duration
+		values []int64
+	}
+	tests := []struct {
+		name          string
+		reservoirSize int
+		updates       []update
+		want          []int64
+		wantCount     int64
+	}{
+		{
+			name:          "in the window",
+			reservoirSize: 10,
+			updates:       []update{{values: []int64{1, 2}}, {after: 30 * time.Second, values: []int64{3}}},
+			want:          []int64{1, 2, 3},
+			wantCount:     3,
+		},
+		{
+			name:          "expired",
+			reservoirSize: 10,
+			updates:       []update{{values: []int64{1, 2}}, {after: 45 * time.Second, values: []int64{3}}, {after: 30 * time.Second, values: []int64{4}}},
+			want:          []int64{3, 4},
+			wantCount:     4,
+		},
+		{
+			name:          "full reservoir",
+			reservoirSize: 3,
+			updates:       []update{{values: []int64{1, 2, 3, 4}}, {after: time.Second, values: []int64{5, 6}}},
+			want:          []int64{4, 5, 6},
+			wantCount:     6,
+		},
+		{
+			name:          "full reservoir expired",
+			reservoirSize: 3,
+			updates:       []update{{values: []int64{1, 2, 3, 4}}, {after: 2 * time.Minute, values: []int64{5}}},
+			want:          []int64{5},
+			wantCount:     5,
+		},
+		{
+			name:          "unbounded",
+			reservoirSize: 0,
+			updates:       []update{{values: make([]int64, 40)}, {after: 2 * time.Minute, values: []int64{1, 2, 3}}},
+			want:          []int64{1, 2, 3},
+			wantCount:     43,
+		},
+		{
+			name:          "all expired",
+			reservoirSize: 3,
+			updates:       []update{{values: []int64{1, 2}}, {after: 2 * time.Minute}},
+			want:          []int64{},
+			wantCount:     2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			s := NewSlidingWindowSampleWithClock(time.Minute, tt.reservoirSize, clock)
+			for _, u := range tt.updates {
+				clock.Advance(u.after)
+				for _, v := range u.values {
+					s.Update(v)
+				}
+			}
+
+			if got := s.Values(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got the values %v, want %v", got, tt.want)
+			}
+			if got := s.Size(); got != len(tt.want) {
+				t.Errorf("size is %d, want %d", got, len(tt.want))
+			}
+			if got := s.Count(); got != tt.wantCount {
+				t.Errorf("count is %d, want %d", got, tt.wantCount)
+			}
+			if _, values := s.TimedValues(); !reflect.DeepEqual(values, tt.want) {
+				t.Errorf("got the timed values %v, want %v", values, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlidingWindowSampleClear(t *testing.T) {
+	clock := newFakeClock()
+	s := NewSlidingWindowSampleWithClock(time.Minute, 2, clock)
+	for _, v := range []int64{1, 2, 3} {
+		s.Update(v)
+	}
+	s.Clear()
+	s.Update(4)
+
+	if got := s.Values(); !reflect.DeepEqual(got, []int64{4}) || s.Count() != 1 {
+		t.Errorf("got the values %v and the count %d after Clear, want [4] and 1", got, s.Count())
+	}
+}
@@ -0,0 +1,93 @@
+package influxdb
+
+import (
+	"strconv"
+	"time"
+)
+
+// sloGrid are the percentiles used to estimate the share of samples under a threshold
+// for timers which don't expose their sample.
+var sloGrid = func() []float64 {
+	ps := make([]float64, 1000)
+	for i := range ps {
+		ps[i] = float64(i+1) / 1000
+	}
+	return ps
+}()
+
+// sloUnits are the units of the names of the SLO fields, from the largest.
+var sloUnits = []struct {
+	d    time.Duration
+	name string
+}{
+	{time.Hour, "h"},
+	{time.Minute, "m"},
+	{time.Second, "s"},
+	{time.Millisecond, "ms"},
+	{time.Microsecond, "us"},
+}
+
+// sloField returns the name of the field of a threshold, as a whole number of the largest unit possible
+// in ASCII, like under_100us, or under_1500us for 1.5ms, so that the name needs no quoting in the queries.
+func sloField(t time.Duration) string {
+	for _, u := range sloUnits {
+		if t != 0 && t%u.d == 0 {
+			return "under_" + strconv.FormatInt(int64(t/u.d), 10) + u.name
+		}
+	}
+	return "under_" + strconv.FormatInt(int64(t), 10) + "ns"
+}
+
+type percentiler interface {
+	Percentiles([]float64) []float64
+}
+
+// sloFields adds an under_<threshold> field to the fields of a timer for each configured threshold,
+// holding the share of sampled durations lower than or equal to the threshold.
+func (r *Reporter) sloFields(name string, i interface{}, fields map[string]interface{}) {
+	thresholds, ok := r.sloThresholds[name]
+	if !ok {
+		thresholds = r.sloThresholds[""]
+	}
+	if len(thresholds) == 0 {
+		return
+	}
+
+	if s, ok := i.(sampled); ok {
+		values := s.Sample().Values()
+		if len(values) == 0 {
+			return
+		}
+		for _, t := range thresholds {
+			var n int
+			for _, v := range values {
+				if v <= t.Nanoseconds() {
+					n++
+				}
+			}
+			fields[sloField(t)] = float64(n) / float64(len(values))
+		}
+		return
+	}
+
+	p, ok := i.(percentiler)
+	if !ok {
+		return
+	}
+	if c, ok := i.(counted); ok && c.Count() == 0 {
+		return
+	}
+
+	// estimate with a grid of percentiles, precise to 0.1%
+	ps := p.Percentiles(sloGrid)
+	for _, t := range thresholds {
+		share := float64(0)
+		for j, v := range ps {
+			if v > float64(t.Nanoseconds()) {
+				break
+			}
+			share = sloGrid[j]
+		}
+		fields[sloField(t)] = share
+	}
+}
@@ -0,0 +1,29 @@
+package influxdb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSLOField(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{100 * time.Microsecond, "under_100us"},
+		{1500 * time.Microsecond, "under_1500us"},
+		{100 * time.Millisecond, "under_100ms"},
+		{time.Second, "under_1s"},
+		{90 * time.Second, "under_90s"},
+		{2 * time.Minute, "under_2m"},
+		{time.Hour, "under_1h"},
+		{1001, "under_1001ns"},
+		{0, "under_0ns"},
+	}
+
+	for _, tt := range tests {
+		if got := sloField(tt.d); got != tt.want {
+			t.Errorf("sloField(%v) is %s, want %s", tt.d, got, tt.want)
+		}
+	}
+}
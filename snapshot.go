@@ -0,0 +1,219 @@
+package influxdb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// entry is a registry entry to report.
+type entry struct {
+	src  *Source
+	name string
+	// metric is the registered metric, and snap a read-only copy of it the fields are computed from.
+	metric interface{}
+	snap   interface{}
+	// totals are the count and the sum of all the values of a windowed histogram or timer
+	totals *window
+	// activity is what tells whether the metric was active since the last send, with idle suppression
+	activity    interface{}
+	hasActivity bool
+}
+
+// snapshot returns the entries to report, with a copy of their values taken while iterating over the registries,
+// so that the points are built without contending with the code updating the metrics.
+func (r *Reporter) snapshot(inv inventory) []entry {
+	res := make([]entry, 0, r.lastEntries)
+	filtered := 0
+
+	seen := make(map[string]struct{}, len(r.knownNames))
+
+	r.each(func(src *Source, name string, i interface{}) {
+		seen[name] = struct{}{}
+		defer func() {
+			if v := recover(); v != nil {
+				r.panicked(v, name)
+			}
+		}()
+
+		inv.add(i)
+
+		if !r.keep(name, i) {
+			filtered++
+			return
+		}
+		if !r.due(i) || !r.tierDue(name) || r.skip(name, i) {
+			return
+		}
+
+		e := entry{
+			src:    src,
+			name:   name,
+			metric: i,
+			snap:   snapshot(i),
+		}
+		if r.windowed {
+			e.totals, _ = windowTotals(i)
+		}
+		if r.maxIdleSkips > 0 {
+			// a gauge is read here, where its panics are recovered
+			e.activity, e.hasActivity = activity(e.snap)
+		}
+		res = append(res, e)
+	})
+	r.lastEntries = len(res)
+	r.dropped(filteredMetrics, int64(filtered))
+	if r.registryDiff {
+		r.diffRegistry(seen)
+	} else {
+		r.knownNames = seen
+	}
+	r.forget(seen)
+
+	return res
+}
+
+// forget drops the state kept between sends for the metrics which are no longer in the registries,
+// so that it doesn't grow with the names used over the life of the process.
+func (r *Reporter) forget(seen map[string]struct{}) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	for name := range r.lastCounters {
+		if _, ok := seen[name]; !ok {
+			delete(r.lastCounters, name)
+		}
+	}
+	for name := range r.lastCounts {
+		if _, ok := seen[name]; !ok {
+			delete(r.lastCounts, name)
+		}
+	}
+	for name := range r.lastValues {
+		if _, ok := seen[name]; !ok {
+			delete(r.lastValues, name)
+		}
+	}
+	for name := range r.lastWindows {
+		if _, ok := seen[name]; !ok {
+			delete(r.lastWindows, name)
+		}
+	}
+	for name := range r.gaugeStats {
+		if _, ok := seen[name]; !ok {
+			delete(r.gaugeStats, name)
+		}
+	}
+	for name := range r.tierCache {
+		if _, ok := seen[name]; !ok {
+			delete(r.tierCache, name)
+		}
+	}
+	for name := range r.idleValues {
+		if _, ok := seen[name]; !ok {
+			delete(r.idleValues, name)
+		}
+	}
+	r.seriesCache.Range(func(k, _ interface{}) bool {
+		if _, ok := seen[k.(seriesKey).name]; !ok {
+			r.seriesCache.Delete(k)
+		}
+		return true
+	})
+}
+
+// snapshot returns a read-only copy of the built-in metrics, and the other ones as they are.
+// Counters and gauges are read once anyway, so they aren't copied.
+func snapshot(i interface{}) interface{} {
+	switch m := i.(type) {
+	case *sampledTimer:
+		return &sampledTimer{Timer: m.Timer.Snapshot(), sample: m.sample.Snapshot()}
+	case metrics.Counter, metrics.Gauge, metrics.GaugeFloat64:
+		return i
+	case metrics.Histogram:
+		return m.Snapshot()
+	case metrics.Meter:
+		return m.Snapshot()
+	case metrics.Timer:
+		return m.Snapshot()
+	case metrics.EWMA:
+		return m.Snapshot()
+	}
+	return i
+}
+
+// built are the points of an entry. Their field maps are owned by the reporter if they were built
+// from a built-in type, and recycled once they are written.
+type built struct {
+	pts   []Point
+	owned bool
+	// start and end locate the points of a built-in type in the arena of the goroutine which built them
+	start, end int
+}
+
+// build returns the points of each entry at the time of the send, built by the configured number of goroutines.
+// The points of the built-in types are only valid until the next call.
+func (r *Reporter) build(host string, es []entry, now time.Time) []built {
+	if cap(r.built) < len(es) {
+		r.built = make([]built, len(es))
+	}
+	res := r.built[:len(es)]
+
+	workers := r.workers
+	if workers > len(es) {
+		workers = len(es)
+	}
+	if workers < 1 {
+		return res
+	}
+	for len(r.arenas) < workers {
+		r.arenas = append(r.arenas, nil)
+	}
+
+	// each goroutine appends the points of the built-in types to its own arena,
+	// instead of allocating a slice per entry
+	run := func(w int) {
+		arena := r.arenas[w][:0]
+		for j := w; j < len(es); j += workers {
+			res[j], arena = r.safeEntryPoints(host, es[j], arena, now)
+		}
+		r.arenas[w] = arena
+	}
+
+	if workers == 1 {
+		run(0)
+	} else {
+		var wg sync.WaitGroup
+		wg.Add(workers)
+		for w := 0; w < workers; w++ {
+			go func(w int) {
+				defer wg.Done()
+				run(w)
+			}(w)
+		}
+		wg.Wait()
+	}
+
+	for j := range res {
+		if res[j].owned {
+			arena := r.arenas[j%workers]
+			res[j].pts = arena[res[j].start:res[j].end:res[j].end]
+		}
+	}
+
+	return res
+}
+
+// entryPoints returns the points of an entry, from a type handler or from the built-in types,
+// whose points are appended to arena.
+func (r *Reporter) entryPoints(host string, e entry, arena []Point, now time.Time) (built, []Point) {
+
+	if pts, ok := handle(e.name, e.metric, now); ok {
+		return built{pts: pts}, arena
+	}
+
+	start := len(arena)
+	arena = r.metricPoints(arena, host, e, now)
+	return built{owned: true, start: start, end: len(arena)}, arena
+}
@@ -0,0 +1,30 @@
+package influxdb
+
+import (
+	"database/sql"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// DBStatsCollector returns a collector maintaining gauges in the registry from the stats of a database handle:
+// <name>.connections.max_open, .open, .in_use and .idle, <name>.wait.count, <name>.wait.seconds,
+// <name>.closed.max_idle, .max_idle_time and .max_lifetime.
+func DBStatsCollector(reg metrics.Registry, name string, db *sql.DB) Collector {
+	gauge := func(n string) metrics.Gauge {
+		return metrics.GetOrRegisterGauge(name+"."+n, reg)
+	}
+
+	return func() {
+		s := db.Stats()
+
+		gauge("connections.max_open").Update(int64(s.MaxOpenConnections))
+		gauge("connections.open").Update(int64(s.OpenConnections))
+		gauge("connections.in_use").Update(int64(s.InUse))
+		gauge("connections.idle").Update(int64(s.Idle))
+		gauge("wait.count").Update(s.WaitCount)
+		metrics.GetOrRegisterGaugeFloat64(name+".wait.seconds", reg).Update(s.WaitDuration.Seconds())
+		gauge("closed.max_idle").Update(s.MaxIdleClosed)
+		gauge("closed.max_idle_time").Update(s.MaxIdleTimeClosed)
+		gauge("closed.max_lifetime").Update(s.MaxLifetimeClosed)
+	}
+}
@@ -0,0 +1,120 @@
+package influxdb
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// Status is the state of the writes of a reporter.
+type Status struct {
+	// LastSuccess is the time of the last successful write, and LastPoints its number of points.
+	LastSuccess time.Time
+	LastPoints  int
+	// LastError is the error of the last failed write, at LastErrorTime.
+	LastError     error
+	LastErrorTime time.Time
+	// Failures is the number of consecutive failed sends.
+	Failures int
+	// Queued is the number of sends waiting for the writer, and Buffered the number of points
+	// waiting for the next flush with a flush interval.
+	Queued   int
+	Buffered int
+	// Written is the number of points written and Errors the number of failed writes, since the start.
+	Written int64
+	Errors  int64
+	// Skipped is the number of sends skipped because the queue was full, BackedOff the number of sends
+	// skipped while backing off after failures, Filtered the number of metrics excluded and Dropped
+	// the number of points dropped before they were written, by the NaN policy, the schema check
+	// or the middlewares, since the start.
+	Skipped   int64
+	BackedOff int64
+	Filtered  int64
+	Dropped   int64
+	// State is the state of the connection to InfluxDB after the last write.
+	State ConnState
+}
+
+// dropKind is a kind of data dropped by the reporter.
+type dropKind int
+
+const (
+	skippedSends dropKind = iota
+	backedOffSends
+	filteredMetrics
+	droppedPoints
+	dropKinds
+)
+
+var dropNames = [dropKinds]string{"skipped_sends", "backed_off_sends", "filtered_metrics", "dropped_points"}
+
+// status guards the Status of a reporter, updated by its goroutines.
+type status struct {
+	mu sync.Mutex
+	s  Status
+}
+
+// Status returns the state of the writes of the reporter, for readiness and liveness checks.
+// It may be called concurrently with Run.
+func (r *Reporter) Status() Status {
+	r.status.mu.Lock()
+	defer r.status.mu.Unlock()
+	return r.status.s
+}
+
+// wrote records the result of writing the batches of a send.
+func (s *status) wrote(points int, err error, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		s.s.LastError = err
+		s.s.LastErrorTime = now
+		s.s.Errors++
+		return
+	}
+	s.s.LastSuccess = now
+	s.s.LastPoints = points
+	s.s.Written += int64(points)
+}
+
+func (s *status) state(failures, queued, buffered int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.s.Failures = failures
+	s.s.Queued = queued
+	s.s.Buffered = buffered
+}
+
+func (s *status) dropped(kind dropKind, n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch kind {
+	case skippedSends:
+		s.s.Skipped += n
+	case backedOffSends:
+		s.s.BackedOff += n
+	case filteredMetrics:
+		s.s.Filtered += n
+	case droppedPoints:
+		s.s.Dropped += n
+	}
+}
+
+// checkHealth marks a healthcheck unhealthy while the writes or the pings of the client fail.
+func (r *Reporter) checkHealth(h metrics.Healthcheck) {
+	st := r.Status()
+	if st.LastError != nil && !st.LastErrorTime.Before(st.LastSuccess) {
+		h.Unhealthy(fmt.Errorf("unable to write metrics to InfluxDB since %s. err=%v", st.LastErrorTime.Format(time.RFC3339), st.LastError))
+		return
+	}
+	if err := r.client.lastPingError(); err != nil {
+		h.Unhealthy(fmt.Errorf("unable to ping InfluxDB. err=%v", err))
+		return
+	}
+	h.Healthy()
+}
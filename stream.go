@@ -0,0 +1,36 @@
+package influxdb
+
+// streaming reports whether the points are written as the batches fill, instead of once per send.
+func (r *Reporter) streaming() bool {
+	return r.maxBatch > 0 && r.flushInterval == 0 && r.pipe != nil
+}
+
+// Middleware transforms the points about to be written, in place or into a new slice. The points must not be
+// kept once it returns. Their tags are shared with other points and from one send to the next,
+// so they must be replaced instead of modified, unlike their fields.
+type Middleware func(pts []Point) []Point
+
+// process runs the middlewares, drops the NaN values, sanitizes and checks the schema of points about to be written.
+func (r *Reporter) process(pts []Point) []Point {
+	n := len(pts)
+	defer func() {
+		r.dropped(droppedPoints, int64(n-len(pts)))
+	}()
+
+	for _, mw := range r.middlewares {
+		pts = mw(pts)
+	}
+	pts = filterNaN(pts, r.nanPolicy)
+
+	if r.sanitizer != nil {
+		for i := range pts {
+			sanitizePoint(&pts[i], r.sanitizer)
+		}
+	}
+
+	if r.schema != nil {
+		pts = r.schema.check(pts)
+	}
+
+	return pts
+}
@@ -0,0 +1,115 @@
+package influxdb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// template splits a dotted metric name into a measurement and tags, like the graphite templates of Telegraf.
+type template struct {
+	filter []string
+	parts  []string
+}
+
+// parseTemplate parses a template of the form "[filter ]template", like
+// "api.* service.resource.method.measurement".
+func parseTemplate(s string) (*template, error) {
+	fs := strings.Fields(s)
+
+	var t template
+	switch len(fs) {
+	case 1:
+		t.parts = strings.Split(fs[0], ".")
+	case 2:
+		t.filter = strings.Split(fs[0], ".")
+		t.parts = strings.Split(fs[1], ".")
+	default:
+		return nil, fmt.Errorf("invalid template %q", s)
+	}
+
+	hasMeasurement := false
+	for i, p := range t.parts {
+		switch p {
+		case "measurement":
+			hasMeasurement = true
+		case "measurement*":
+			if i != len(t.parts)-1 {
+				return nil, fmt.Errorf("invalid template %q: measurement* must be the last part", s)
+			}
+			hasMeasurement = true
+		}
+	}
+	if !hasMeasurement {
+		return nil, fmt.Errorf("invalid template %q: no measurement part", s)
+	}
+
+	return &t, nil
+}
+
+// match reports whether the filter of the template matches the parts of a name.
+func (t *template) match(name []string) bool {
+	if t.filter == nil {
+		return true
+	}
+
+	for i, f := range t.filter {
+		if i >= len(name) {
+			return false
+		}
+		if f == "*" {
+			continue
+		}
+		if i == len(t.filter)-1 && strings.HasSuffix(f, "*") {
+			return strings.HasPrefix(name[i], strings.TrimSuffix(f, "*"))
+		}
+		if f != name[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// apply returns the measurement and the tags extracted from the parts of a name.
+// Empty template parts are skipped, parts of the name beyond the template are ignored.
+func (t *template) apply(name []string) (string, map[string]string) {
+	var measurement []string
+	tags := make(map[string]string)
+
+	for i, p := range t.parts {
+		if i >= len(name) {
+			break
+		}
+
+		switch p {
+		case "":
+		case "measurement":
+			measurement = append(measurement, name[i])
+		case "measurement*":
+			measurement = append(measurement, name[i:]...)
+		default:
+			if v, ok := tags[p]; ok {
+				tags[p] = v + "." + name[i]
+			} else {
+				tags[p] = name[i]
+			}
+		}
+	}
+
+	return strings.Join(measurement, "."), tags
+}
+
+// applyTemplates applies the first matching template to a name.
+// The name is returned as the measurement when no template matches.
+func applyTemplates(templates []*template, name string) (string, map[string]string) {
+	parts := strings.Split(name, ".")
+	for _, t := range templates {
+		if !t.match(parts) {
+			continue
+		}
+		if measurement, tags := t.apply(parts); measurement != "" {
+			return measurement, tags
+		}
+	}
+	return name, nil
+}
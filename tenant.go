@@ -0,0 +1,32 @@
+package influxdb
+
+// addPoints appends points to the batch of their database. With tenant databases, the points
+// with the tenant tag go to the database of their tenant instead.
+func (r *Reporter) addPoints(bs batches, db string, pts []Point) {
+	if r.tenantTag == "" {
+		r.appendBatch(bs, db, pts)
+		return
+	}
+
+	for i := range pts {
+		r.appendBatch(bs, r.databaseOf(db, &pts[i]), pts[i:i+1])
+	}
+}
+
+// databaseOf returns the database a point meant for db is written to, given its tenant.
+func (r *Reporter) databaseOf(db string, pt *Point) string {
+	if r.tenantTag == "" {
+		return db
+	}
+	if v := pt.Tags[r.tenantTag]; v != "" {
+		return r.tenantPrefix + v
+	}
+	return db
+}
+
+func (r *Reporter) appendBatch(bs batches, db string, pts []Point) {
+	if _, ok := bs[db]; !ok {
+		bs[db] = r.newPoints(db)
+	}
+	bs[db] = append(bs[db], pts...)
+}
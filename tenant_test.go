@@ -0,0 +1,35 @@
+package influxdb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestTenantDatabases(t *testing.T) {
+	influx := newFakeInflux(t)
+	shared, acme := metrics.NewRegistry(), metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", shared).Inc(1)
+	metrics.GetOrRegisterCounter("requests", acme).Inc(2)
+	r := newTestReporter(t, influx.URL, shared,
+		WithTenantDatabases("tenant", "tenant_"),
+		WithTaggedRegistry(acme, "", map[string]string{"tenant": "acme"}),
+	)
+	r.WritePoints(Point{Measurement: "deploy", Tags: map[string]string{"tenant": "globex"}, Fields: map[string]interface{}{"value": int64(3)}})
+
+	if err := r.Send(); err != nil {
+		t.Fatalf("unable to send. err=%v", err)
+	}
+
+	// the points without the tag stay in the database of the reporter
+	for db, want := range map[string][]string{
+		"db":            {"requests.count value=1i"},
+		"tenant_acme":   {"requests.count,tenant=acme value=2i"},
+		"tenant_globex": {"deploy,tenant=globex value=3i"},
+	} {
+		if got := influx.lines(db); !reflect.DeepEqual(got, want) {
+			t.Errorf("got the lines %q in %s, want %q", got, db, want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package influxdb
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"time"
+)
+
+// Tier reports the metrics whose names match one of its patterns less often or less completely,
+// so that very large registries trade completeness for write volume, like a debug tier reported
+// every minute and a tenth of the time while the critical metrics are reported at every interval.
+type Tier struct {
+	Name     string
+	Patterns []string
+	// Interval reports the metrics of the tier at most this often. It must be a multiple of the interval
+	// of the reporter; 0 reports them at every send.
+	Interval time.Duration
+	// Probability is the chance a metric of the tier is reported by a send it is due for,
+	// drawn for each metric and each send. 1 reports them every time and 0 never does.
+	Probability float64
+}
+
+// tier is a compiled Tier.
+type tier struct {
+	Tier
+	res   []*regexp.Regexp
+	every int64
+}
+
+func compileTiers(tiers []Tier, interval time.Duration) ([]*tier, error) {
+	res := make([]*tier, 0, len(tiers))
+	for _, t := range tiers {
+		if t.Probability < 0 || t.Probability > 1 {
+			return nil, fmt.Errorf("invalid probability %v of tier %s", t.Probability, t.Name)
+		}
+		if t.Interval < 0 || (t.Interval > 0 && (interval <= 0 || t.Interval < interval || t.Interval%interval != 0)) {
+			return nil, fmt.Errorf("the interval %s of tier %s is not a multiple of the interval %s", t.Interval, t.Name, interval)
+		}
+		patterns, err := compilePatterns(t.Patterns)
+		if err != nil {
+			return nil, err
+		}
+
+		ct := &tier{Tier: t, res: patterns, every: 1}
+		if t.Interval > 0 {
+			ct.every = int64(t.Interval / interval)
+		}
+		res = append(res, ct)
+	}
+	return res, nil
+}
+
+// tierOf returns the first tier matching a name, or nil. The result is cached by name.
+func (r *Reporter) tierOf(name string) *tier {
+	t, ok := r.tierCache[name]
+	if ok {
+		return t
+	}
+	for _, ct := range r.compiledTiers {
+		if matchAny(ct.res, name) {
+			t = ct
+			break
+		}
+	}
+	r.tierCache[name] = t
+	return t
+}
+
+// tierDue reports whether a metric is reported by the current send, given its tier.
+func (r *Reporter) tierDue(name string) bool {
+	if len(r.compiledTiers) == 0 {
+		return true
+	}
+	t := r.tierOf(name)
+	if t == nil {
+		return true
+	}
+	if (r.sends-1)%t.every != 0 {
+		return false
+	}
+	return t.Probability == 1 || rand.Float64() < t.Probability
+}
+
+// setTierIntervals rounds the intervals of the tiers to multiples of a new interval.
+func (r *Reporter) setTierIntervals(d time.Duration) {
+	for _, t := range r.compiledTiers {
+		if t.Interval <= 0 {
+			continue
+		}
+		n := int64((t.Interval + d/2) / d)
+		if n < 1 {
+			n = 1
+		}
+		t.every = n
+	}
+}
@@ -0,0 +1,55 @@
+package influxdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestTiers(t *testing.T) {
+	reg := metrics.NewRegistry()
+	for _, name := range []string{"critical", "debug.always", "debug.never", "sampled", "other"} {
+		metrics.GetOrRegisterCounter(name, reg).Inc(1)
+	}
+	r := newTestReporter(t, unreachable, reg, WithCounterDeltas(false), WithTiers(
+		Tier{Name: "critical", Patterns: []string{"^critical"}, Probability: 1},
+		Tier{Name: "debug", Patterns: []string{`^debug\.always`}, Interval: 2 * time.Second, Probability: 1},
+		Tier{Name: "off", Patterns: []string{`^debug\.never`}, Probability: 0},
+		Tier{Name: "sampled", Patterns: []string{"^sampled"}, Probability: 0.5},
+	))
+
+	const sends = 1000
+	counts := make(map[string]int)
+	for i := 0; i < sends; i++ {
+		for m := range snapshotPoints(t, r) {
+			counts[m]++
+		}
+	}
+
+	for _, tt := range []struct {
+		measurement string
+		min, max    int
+	}{
+		{"critical.count", sends, sends},
+		{"other.count", sends, sends},
+		{"debug.always.count", sends / 2, sends / 2},
+		{"debug.never.count", 0, 0},
+		{"sampled.count", sends * 4 / 10, sends * 6 / 10},
+	} {
+		if got := counts[tt.measurement]; got < tt.min || got > tt.max {
+			t.Errorf("%s reported %d times out of %d, want between %d and %d", tt.measurement, got, sends, tt.min, tt.max)
+		}
+	}
+}
+
+func TestTiersInvalid(t *testing.T) {
+	for _, tier := range []Tier{
+		{Name: "probability", Probability: 1.5},
+		{Name: "interval", Interval: 1500 * time.Millisecond, Probability: 1},
+	} {
+		if _, err := New(metrics.NewRegistry(), time.Second, unreachable, "db", "", "", WithTiers(tier)); err == nil {
+			t.Errorf("tier %s accepted, want an error", tier.Name)
+		}
+	}
+}
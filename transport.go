@@ -0,0 +1,137 @@
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/client"
+)
+
+// TransportOptions tune the connections of the writes, for reporters writing often to a far away InfluxDB,
+// like a cloud region on another continent, which suffer from the default connection churn.
+// The zero values keep the defaults of the InfluxDB client.
+type TransportOptions struct {
+	// MaxIdleConns and MaxIdleConnsPerHost bound the idle connections kept open, 100 and 2 by default.
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout closes the connections idle for longer, never by default. It should be longer than the interval.
+	IdleConnTimeout time.Duration
+	// KeepAlive is the interval of the TCP keep-alive probes, 15 seconds by default; negative disables them.
+	KeepAlive time.Duration
+	// HTTP2 writes over HTTP/2 when the server supports it. The default is HTTP/1.1.
+	HTTP2 bool
+}
+
+// WithTransport writes the points, pings and queries InfluxDB with an HTTP transport tuned with opts,
+// instead of the one of the InfluxDB client.
+func WithTransport(opts TransportOptions) ClientOption {
+	return func(c *Client) {
+		c.transport = &opts
+	}
+}
+
+// WithDialer opens the connections to InfluxDB with dial, like the DialContext of a SOCKS proxy, of a VPN mesh
+// or of a net.Dialer bound to an interface, instead of dialing directly.
+func WithDialer(dial func(ctx context.Context, network, address string) (net.Conn, error)) ClientOption {
+	return func(c *Client) {
+		c.dial = dial
+	}
+}
+
+// ownHTTP reports whether the client talks to InfluxDB with its own HTTP client, instead of the one of
+// the InfluxDB client which can't be configured.
+func (c *Client) ownHTTP() bool {
+	return c.codec != nil || c.transport != nil || c.dial != nil
+}
+
+// newTransport returns the transport of the client, with the tuning of the options and the dialer, if any.
+func (c *Client) newTransport(tr *http.Transport) *http.Transport {
+	opts := c.transport
+	if opts == nil {
+		tr.DialContext = c.dial
+		return tr
+	}
+
+	tr.MaxIdleConns = opts.MaxIdleConns
+	if tr.MaxIdleConns == 0 {
+		tr.MaxIdleConns = 100
+	}
+	tr.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	tr.IdleConnTimeout = opts.IdleConnTimeout
+	tr.ForceAttemptHTTP2 = opts.HTTP2
+	tr.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: opts.KeepAlive,
+	}).DialContext
+	if c.dial != nil {
+		tr.DialContext = c.dial
+	}
+	return tr
+}
+
+// request sends a request to an endpoint of InfluxDB with the HTTP client of the client.
+func (c *Client) request(method, endpoint string, query map[string]string) (*http.Response, error) {
+	c.mu.RLock()
+	config, hc := c.config, c.http
+	c.mu.RUnlock()
+
+	u := config.URL
+	u.Path = path.Join(u.Path, endpoint)
+	q := u.Query()
+	for k, v := range query {
+		q.Set(k, v)
+	}
+
+	var body io.Reader
+	if method == "POST" {
+		body = strings.NewReader(q.Encode())
+	} else {
+		u.RawQuery = q.Encode()
+	}
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	req.Header.Set("User-Agent", "InfluxDBClient")
+	if config.Username != "" {
+		req.SetBasicAuth(config.Username, config.Password)
+	}
+
+	return hc.Do(req)
+}
+
+// pingHTTP pings InfluxDB with the HTTP client of the client.
+func (c *Client) pingHTTP() error {
+	resp, err := c.request("GET", "ping", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// queryHTTP runs a query with the HTTP client of the client.
+func (c *Client) queryHTTP(command string) (*client.Response, error) {
+	resp, err := c.request("POST", "query", map[string]string{"q": command})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var res client.Response
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("unable to decode the answer of InfluxDB, %s. err=%v", resp.Status, err)
+	}
+	return &res, nil
+}
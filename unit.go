@@ -0,0 +1,47 @@
+package influxdb
+
+import (
+	"strings"
+)
+
+// conventionalUnits maps the last part of metric names to units.
+var conventionalUnits = map[string]string{
+	"bytes":   "bytes",
+	"ns":      "ns",
+	"us":      "us",
+	"ms":      "ms",
+	"seconds": "s",
+	"percent": "percent",
+	"ops":     "ops",
+}
+
+// conventionalUnit derives a unit from the last part of a metric name, like api.response_bytes.
+func conventionalUnit(name string) string {
+	i := strings.LastIndexAny(name, "._-")
+	return conventionalUnits[name[i+1:]]
+}
+
+// unit returns the unit of a metric, or an empty string if it is unknown.
+func (r *Reporter) unit(name, typ string) string {
+	if u, ok := r.units[name]; ok {
+		return u
+	}
+
+	r.mu.Lock()
+	m, ok := r.meta[name]
+	r.mu.Unlock()
+	if ok && m.unit != "" {
+		return m.unit
+	}
+
+	if r.unitConventions {
+		if u := conventionalUnit(name); u != "" {
+			return u
+		}
+	}
+
+	if typ == TypeTimer {
+		return unitName(r.durationUnit)
+	}
+	return ""
+}
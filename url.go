@@ -0,0 +1,67 @@
+package influxdb
+
+import (
+	"fmt"
+	"net"
+	uurl "net/url"
+	"strconv"
+	"strings"
+)
+
+// parseURL parses the url of an InfluxDB server. It defaults to http when the url has no scheme,
+// like influxdb:8086, and accepts IPv6 literals without brackets when they have no port, like http://fe80::1.
+// The port of an IPv6 literal needs the brackets: http://[fe80::1]:8086.
+func parseURL(url string) (*uurl.URL, error) {
+	s := strings.TrimSpace(url)
+	if !strings.Contains(s, "://") {
+		s = "http://" + s
+	}
+	s = bracketIPv6(s)
+
+	u, err := uurl.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("the scheme %s is not http or https", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("no host")
+	}
+	if port := u.Port(); port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("invalid port %s", port)
+		}
+	}
+	return u, nil
+}
+
+// bracketIPv6 puts the brackets around the host of a url if it is an IPv6 literal without them.
+func bracketIPv6(s string) string {
+	i := strings.Index(s, "://") + len("://")
+	end := strings.IndexAny(s[i:], "/?#")
+	if end < 0 {
+		end = len(s) - i
+	}
+	authority := s[i : i+end]
+
+	host := authority
+	if at := strings.LastIndexByte(host, '@'); at >= 0 {
+		host = host[at+1:]
+	}
+	if strings.HasPrefix(host, "[") || strings.Count(host, ":") < 2 {
+		return s
+	}
+	// a zone, like fe80::1%eth0, must be escaped in a url. Without the brackets, the url isn't escaped,
+	// so the zone is taken as it is, even when it starts with 25.
+	addr, zone, _ := strings.Cut(host, "%")
+	if net.ParseIP(addr) == nil {
+		return s
+	}
+	if zone != "" {
+		zone = "%25" + strings.ReplaceAll(zone, "%", "%25")
+	}
+
+	start := i + len(authority) - len(host)
+	return s[:start] + "[" + addr + zone + "]" + s[i+end:]
+}
@@ -0,0 +1,95 @@
+package influxdb
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// supervise runs the loop until Stop is called, restarting it when it panics, and watches it
+// for stalls: a loop which made no progress for twice the interval is logged, counted and passed
+// to the error handler. A stalled loop can't be interrupted safely, so it is only restarted if it dies.
+func (r *Reporter) supervise() {
+	done := make(chan struct{})
+	defer close(done)
+	atomic.StoreInt64(&r.watched, int64(r.interval))
+	go r.watch(done)
+
+	for !r.runRecovered() {
+		if r.self != nil {
+			r.self.restarts.Inc(1)
+		}
+		// wait an interval in case the loop dies right away again
+		r.progressed()
+		sleep(r.clock, r.interval)
+	}
+}
+
+// runRecovered runs the loop, and reports whether it returned because Stop was called.
+func (r *Reporter) runRecovered() (stopped bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			r.logger.logf(LogError, "the reporting loop died, restarting it. err=%v", v)
+			if r.pipe != nil {
+				// the writer of the dead loop must finish its jobs and exit before the next one starts,
+				// since they share the encoder and the sinks
+				r.pipe.close()
+				for err := range r.pipe.done {
+					r.written(err)
+				}
+			}
+			stopped = false
+		}
+	}()
+
+	r.run()
+	return true
+}
+
+// watch checks the progress of the loop at each interval, until done is closed.
+func (r *Reporter) watch(done chan struct{}) {
+	interval := time.Duration(atomic.LoadInt64(&r.watched))
+	c, stop := tick(r.clock, interval)
+	defer func() {
+		stop()
+	}()
+
+	stalled := false
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-c:
+			if d := time.Duration(atomic.LoadInt64(&r.watched)); d != interval {
+				// follow the changes of SetInterval
+				stop()
+				interval = d
+				c, stop = tick(r.clock, interval)
+			}
+
+			idle := now.Sub(time.Unix(0, atomic.LoadInt64(&r.progress)))
+			if idle <= 2*interval {
+				stalled = false
+				continue
+			}
+			if stalled {
+				continue
+			}
+			stalled = true
+
+			err := fmt.Errorf("the reporting loop made no progress for %s", idle.Round(time.Millisecond))
+			r.logger.logf(LogError, "the reporting loop is stalled. err=%v", err)
+			if r.self != nil {
+				r.self.stalls.Inc(1)
+			}
+			if r.errorHandler != nil {
+				r.errorHandler(err)
+			}
+		}
+	}
+}
+
+// progressed records that the loop handled an event, for the watchdog.
+func (r *Reporter) progressed() {
+	atomic.StoreInt64(&r.progress, r.clock.Now().UnixNano())
+}
@@ -0,0 +1,86 @@
+package influxdb
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// panicSink panics on its first write.
+type panicSink struct {
+	writes int
+}
+
+func (s *panicSink) Write(string, []Point) error {
+	s.writes++
+	if s.writes == 1 {
+		panic("sink bug")
+	}
+	return nil
+}
+
+// requestLines keeps the lines of the requests counter.
+func requestLines(lines []string) []string {
+	var res []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "requests.count ") {
+			res = append(res, line)
+		}
+	}
+	return res
+}
+
+func TestWatchdogWriterPanic(t *testing.T) {
+	influx := newFakeInflux(t)
+	clock := newFakeClock()
+	reg := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", reg).Inc(1)
+	r := newTestReporter(t, influx.URL, reg, WithClock(clock), WithLogger(NopLogger), WithWatchdog(), WithSinks(&panicSink{}))
+
+	go r.Run()
+	clock.waitTimers(2)
+	clock.Advance(time.Second)
+	clock.Advance(time.Second)
+	r.Stop()
+
+	if got := requestLines(influx.lines("db")); len(got) != 1 {
+		t.Errorf("got the lines %q, want the one of the second send", got)
+	}
+	if st := r.Status(); st.Errors != 1 || st.Failures != 0 {
+		t.Errorf("got %d errors and %d consecutive failures, want 1 and 0", st.Errors, st.Failures)
+	}
+}
+
+func TestWatchdogLoopPanic(t *testing.T) {
+	influx := newFakeInflux(t)
+	clock := newFakeClock()
+	reg := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("requests", reg).Inc(1)
+	calls := 0
+	bug := func(pts []Point) []Point {
+		calls++
+		if calls == 1 {
+			panic("middleware bug")
+		}
+		return pts
+	}
+	r := newTestReporter(t, influx.URL, reg, WithClock(clock), WithLogger(NopLogger), WithWatchdog(), WithSelfMetrics("influx"), WithMiddlewares(bug))
+
+	go r.Run()
+	clock.waitTimers(2)
+	clock.Advance(time.Second)
+	for r.self.restarts.Count() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// the loop starts again after an interval
+	clock.Advance(time.Second)
+	clock.waitTimers(2)
+	clock.Advance(time.Second)
+	r.Stop()
+
+	if got := requestLines(influx.lines("db")); len(got) != 1 {
+		t.Errorf("got the lines %q, want the one of the restarted loop", got)
+	}
+}
@@ -0,0 +1,101 @@
+package influxdb
+
+import (
+	"sync/atomic"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// totalSample is a sample which keeps the count and the sum of every value it was given, and not only
+// of the values in its reservoir, so that the window stats hold the values recorded since the last send.
+type totalSample struct {
+	metrics.Sample
+	count int64
+	sum   int64
+}
+
+func (s *totalSample) Update(v int64) {
+	atomic.AddInt64(&s.count, 1)
+	atomic.AddInt64(&s.sum, v)
+	s.Sample.Update(v)
+}
+
+func (s *totalSample) Clear() {
+	s.Sample.Clear()
+	atomic.StoreInt64(&s.count, 0)
+	atomic.StoreInt64(&s.sum, 0)
+}
+
+func (s *totalSample) totals() window {
+	return window{count: atomic.LoadInt64(&s.count), sum: atomic.LoadInt64(&s.sum)}
+}
+
+// NewWindowedHistogram constructs a histogram backed by s which keeps the sum of all the values recorded,
+// for the window_sum and window_mean fields of WithWindowedStats.
+func NewWindowedHistogram(s metrics.Sample) metrics.Histogram {
+	return metrics.NewHistogram(&totalSample{Sample: s})
+}
+
+// NewWindowedTimer constructs a timer backed by s which keeps the sum of all the durations recorded,
+// for the window_sum and window_mean fields of WithWindowedStats.
+func NewWindowedTimer(s metrics.Sample) metrics.Timer {
+	ts := &totalSample{Sample: s}
+	return &sampledTimer{
+		Timer:  metrics.NewCustomTimer(metrics.NewHistogram(ts), metrics.NewMeter()),
+		sample: ts,
+	}
+}
+
+// windowTotals returns the count and the sum of all the values of a windowed histogram or timer.
+func windowTotals(i interface{}) (*window, bool) {
+	s, ok := i.(sampled)
+	if !ok {
+		return nil, false
+	}
+	ts, ok := s.Sample().(*totalSample)
+	if !ok {
+		return nil, false
+	}
+	w := ts.totals()
+	return &w, true
+}
+
+// window is the count and the sum of a histogram or timer at the last send.
+type window struct {
+	count int64
+	sum   int64
+}
+
+// windowFields adds the count of the values recorded since the last send to the fields of a histogram
+// or timer, and their sum and mean for the windowed ones, whose sum isn't limited to their reservoir.
+// unit divides the sum and the mean.
+func (r *Reporter) windowFields(e entry, unit float64, fields map[string]interface{}) {
+	c, ok := e.snap.(counted)
+	if !ok {
+		return
+	}
+	cur := window{count: c.Count()}
+	if e.totals != nil {
+		cur = *e.totals
+	}
+
+	r.stateMu.Lock()
+	last, seen := r.lastWindows[e.name]
+	r.lastWindows[e.name] = cur
+	r.stateMu.Unlock()
+	if !seen || cur.count < last.count {
+		// first send or the metric was cleared
+		last = window{}
+	}
+
+	n := cur.count - last.count
+	fields["window_count"] = n
+	if e.totals == nil {
+		return
+	}
+	total := float64(cur.sum-last.sum) / unit
+	fields["window_sum"] = total
+	if n > 0 {
+		fields["window_mean"] = total / float64(n)
+	}
+}
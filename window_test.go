@@ -0,0 +1,79 @@
+package influxdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestWindowedHistogram(t *testing.T) {
+	tests := []struct {
+		name    string
+		updates [][]int64
+		want    []map[string]interface{}
+	}{
+		{
+			name:    "values since the last send",
+			updates: [][]int64{{4, 6}, {10}},
+			want: []map[string]interface{}{
+				{"window_count": int64(2), "window_sum": 10.0, "window_mean": 5.0},
+				{"window_count": int64(1), "window_sum": 10.0, "window_mean": 10.0},
+			},
+		},
+		{
+			name:    "no values",
+			updates: [][]int64{{1}, nil},
+			want: []map[string]interface{}{
+				{"window_count": int64(1), "window_sum": 1.0, "window_mean": 1.0},
+				{"window_count": int64(0), "window_sum": 0.0},
+			},
+		},
+		{
+			// the sums hold the values which fell out of the reservoir
+			name:    "beyond the reservoir",
+			updates: [][]int64{{1, 2, 3, 4}},
+			want: []map[string]interface{}{
+				{"window_count": int64(4), "window_sum": 10.0, "window_mean": 2.5},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := metrics.NewRegistry()
+			h := NewWindowedHistogram(metrics.NewUniformSample(2))
+			reg.Register("sizes", h)
+			r := newTestReporter(t, unreachable, reg, WithWindowedStats(true))
+
+			for i, values := range tt.updates {
+				for _, v := range values {
+					h.Update(v)
+				}
+				fields := snapshotPoints(t, r)["sizes.histogram"].Fields
+				for k, want := range tt.want[i] {
+					if got := fields[k]; got != want {
+						t.Errorf("send %d: %s is %v, want %v", i, k, got, want)
+					}
+				}
+				if _, ok := tt.want[i]["window_mean"]; !ok && fields["window_mean"] != nil {
+					t.Errorf("send %d: window_mean is %v, want none", i, fields["window_mean"])
+				}
+			}
+		})
+	}
+}
+
+func TestWindowedTimerUnit(t *testing.T) {
+	reg := metrics.NewRegistry()
+	tm := NewWindowedTimer(metrics.NewUniformSample(10))
+	reg.Register("latency", tm)
+	r := newTestReporter(t, unreachable, reg, WithWindowedStats(true), WithDurationUnit(time.Millisecond))
+
+	tm.Update(10 * time.Millisecond)
+	tm.Update(30 * time.Millisecond)
+	fields := snapshotPoints(t, r)["latency.timer"].Fields
+	if fields["window_sum"] != 40.0 || fields["window_mean"] != 20.0 {
+		t.Errorf("window_sum is %v and window_mean %v, want 40 and 20", fields["window_sum"], fields["window_mean"])
+	}
+}